@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -9,60 +9,212 @@ import (
 
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/shell-sage/internal/guard"
 	"github.com/shell-sage/internal/logger"
+	"github.com/shell-sage/internal/logs"
 	"github.com/shell-sage/internal/metrics"
+	"github.com/shell-sage/internal/pipeline"
 	"github.com/shell-sage/internal/spinner"
 	"github.com/shell-sage/internal/ui"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-const maxLogChars = 2000
+var (
+	analyzeJobs    int
+	analyzeRaw     bool
+	analyzeContext int
+	analyzeFollow  bool
+	analyzeBurst   int
+	analyzeWindow  time.Duration
+	analyzeLevel   string
+	analyzeSince   string
+	analyzeFields  []string
+)
 
 var analyzeCmd = &cobra.Command{
-	Use:   "analyze [file]",
-	Short: "Analyze an error log file and summarize critical issues",
-	Args:  cobra.ExactArgs(1),
+	Use:   "analyze [file|glob|-]...",
+	Short: "Analyze error logs and summarize critical issues",
+	Long: `Analyze one or more logs and summarize their critical issues.
+
+Logs can be files, glob patterns or '-' for stdin, and may be compressed with
+gzip, bzip2 or zstd (zstd needs the zstd tool). With no arguments the log is
+read from stdin when it is piped:
+
+  kubectl logs my-pod | ssage analyze
+  ssage analyze 'logs/*.log' /var/log/app.log.2.gz
+
+With --follow, ssage watches a growing log instead, like 'tail -F', and
+whenever errors burst (--burst error lines within --window) it sends just
+that burst for a short diagnosis:
+
+  ssage analyze --follow ./dev-server.log
+  docker compose logs -f | ssage analyze --follow --burst 3 --window 10s
+
+JSON lines, logfmt, 'journalctl -o json' exports and nginx/Apache access logs
+are recognized and sent as a compact view (time, level, service, message and
+error fields), which can be filtered locally first:
+
+  ssage analyze --level error --since 10m --field service=api app.jsonl
+  journalctl -u nginx -o json --since today | ssage analyze --level warn`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && stdinIsTerminal() {
+			return fmt.Errorf("requires a log file, a glob or '-' for stdin")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		start := time.Now()
-		filePath := args[0]
+		ctx := cmd.Context()
+		if len(args) == 0 {
+			args = []string{logs.Stdin}
+		}
+		filter, err := logFilter(start)
+		if err != nil {
+			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+			os.Exit(2)
+		}
+		if analyzeFollow {
+			if len(args) > 1 || strings.ContainsAny(args[0], "*?[") {
+				fmt.Println(ui.ErrorStyle().Render("❌ --follow watches a single log."))
+				os.Exit(2)
+			}
+			if filter.Active() {
+				fmt.Println(ui.ErrorStyle().Render("❌ --follow cannot be combined with --level, --since or --field."))
+				os.Exit(2)
+			}
+			followLog(ctx, args[0])
+			return
+		}
+		if analyzeRaw && filter.Active() {
+			fmt.Println(ui.ErrorStyle().Render("❌ --raw sends the log as is and cannot be combined with --level, --since or --field."))
+			os.Exit(2)
+		}
 
-		logger.Log.WithField("file", filePath).Info("Starting 'analyze' command")
+		logger.Log.WithField("inputs", args).Info("Starting 'analyze' command")
 
-		content, err := os.ReadFile(filePath)
+		sources, err := logs.Read(args, os.Stdin)
 		if err != nil {
 			elapsed := time.Since(start)
 			logger.Log.WithError(err).Error("Failed to read log file")
 			metrics.Record("analyze", elapsed, err.Error())
-			fmt.Println(ui.ErrorStyle().Render("❌ Error reading file: " + err.Error()))
+			fmt.Println(ui.ErrorStyle().Render("❌ Error reading log: " + err.Error()))
+			return
+		}
+		fullSize := 0
+		for _, src := range sources {
+			fullSize += len(src.Text)
+		}
+		if fullSize == 0 {
+			fmt.Println(ui.ErrorStyle().Render("⚠️  The log is empty."))
 			return
 		}
+		for _, src := range sources {
+			warnInjection(src.Name, src.Text, true)
+		}
+		name := sources[0].Name
+		if len(sources) > 1 {
+			name = fmt.Sprintf("%d logs", len(sources))
+		}
 
-		logContent := string(content)
-		fullSize := len(logContent)
-		logger.Log.WithField("file_size_chars", fullSize).Info("Log file read")
+		dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+		structured := false
+		if !analyzeRaw {
+			if sources, structured = structuredView(sources, filter); len(sources) == 0 {
+				fmt.Println(ui.ErrorStyle().Render("⚠️  No records match the filters."))
+				return
+			}
+		}
 
-		if fullSize > maxLogChars {
-			fmt.Printf("⚠️  Log file is large (%d chars). Send full content to AI? This may be slow. [y/N]: ", fullSize)
-			reader := bufio.NewReader(os.Stdin)
-			input, _ := reader.ReadString('\n')
-			if strings.TrimSpace(strings.ToLower(input)) != "y" {
-				logContent = logContent[:maxLogChars] + "\n...[truncated]..."
-				logger.Log.WithField("truncated_at", maxLogChars).Info("Log truncated by user choice")
-				fmt.Println("📄 Using first 2000 characters.")
+		excerpt := false
+		if !analyzeRaw {
+			var total logs.Excerpt
+			filtered := make([]logs.Source, 0, len(sources))
+			for _, src := range sources {
+				ex := logs.Extract(src.Text, analyzeContext)
+				total.TotalLines += ex.TotalLines
+				total.KeptLines += ex.KeptLines
+				total.Issues += ex.Issues
+				total.Occurrences += ex.Occurrences
+				if ex.Issues > 0 {
+					filtered = append(filtered, logs.Source{Name: src.Name, Text: ex.Text})
+				}
+			}
+			logger.Log.WithFields(logrus.Fields{
+				"lines":       total.TotalLines,
+				"kept":        total.KeptLines,
+				"issues":      total.Issues,
+				"occurrences": total.Occurrences,
+			}).Info("Log pre-filtered")
+			if total.Issues > 0 {
+				sources = filtered
+				excerpt = true
+				fmt.Println(dim.Render(fmt.Sprintf("🔎 Kept %d of %d lines (issues: %d distinct, %d total), dropped %d. Use --raw to send the whole log.",
+					total.KeptLines, total.TotalLines, total.Issues, total.Occurrences, total.Dropped())))
 			} else {
-				logger.Log.Info("User chose to send full log")
-				fmt.Println("📄 Sending full log to AI...")
+				fmt.Println(dim.Render("🔎 No error or warning lines found, analyzing the whole log."))
 			}
 		}
 
-		lang := "English"
-		if LangFlag != "" {
-			lang = LangFlag
+		opts := generationOptions("analyze")
+		size := logs.ChunkSize(opts.NumCtx)
+		prompt := logs.Join(sources)
+		var chunks []logs.Chunk
+		if len(prompt) > size {
+			chunks = logs.SplitSources(sources, size)
+		}
+		logger.Log.WithFields(logrus.Fields{
+			"sources":         len(sources),
+			"file_size_chars": fullSize,
+			"chunks":          len(chunks),
+		}).Info("Log file read")
+
+		if len(chunks) > 1 {
+			msg := fmt.Sprintf("⚠️  Log is large (%d chars): analyzing all of it takes %d requests.", fullSize, len(chunks)+1)
+			if !stdinIsTerminal() {
+				// No one to ask: the log was piped in or ssage runs in a script.
+				fmt.Println(msg + " Analyzing everything.")
+			} else {
+				fmt.Print(msg + " Continue? Otherwise only the end of the log is sent. [y/N]: ")
+				input, err := readLine(ctx)
+				if err != nil {
+					fmt.Println()
+					metrics.RecordCancelled("analyze", time.Since(start))
+					return
+				}
+				if strings.TrimSpace(strings.ToLower(input)) != "y" {
+					last := chunks[len(chunks)-1]
+					chunks = nil
+					prompt = last.Text
+					logger.Log.WithField("first_line", last.FirstLine).Info("Log truncated by user choice")
+					if excerpt {
+						fmt.Println("📄 Using the last issues found.")
+					} else {
+						fmt.Printf("📄 Using lines %d-%d%s.\n", last.FirstLine, last.LastLine, sourceSuffix(last))
+					}
+				} else {
+					logger.Log.Info("User chose to analyze the full log")
+				}
+			}
+		}
+
+		system := "You are a sysadmin. Analyze the log the user sends and summarize the critical errors in max 4 bullet points, no intro."
+		if excerpt {
+			system = "You are a sysadmin. The user sends excerpts of a log: error and warning lines and stack traces with the lines around them, grouped by issue. A header like '--- line 7 (×10, last at line 106) ---' gives where an issue was first seen and how often it repeated. Summarize the critical errors in max 4 bullet points, no intro."
+		}
+		if structured {
+			system += " Structured log lines were rewritten as 'time LEVEL [service] message error-fields'."
+		}
+		if len(sources) > 1 {
+			system += " Several logs are included, each starting with a '==> name <==' header; say which log each error comes from."
+		}
+		req := pipeline.Request{
+			System:  guardedSystemPrompt(system),
+			Prompt:  guard.Fence("log", prompt),
+			Command: "analyze",
+			Options: opts,
+			Meta:    &pipeline.Meta{},
 		}
-		prompt := fmt.Sprintf(
-			"IMPORTANT: You MUST respond ONLY in %s. Do not use any other language.\nYou are a sysadmin. Analyze this log and summarize the critical errors in max 4 bullet points, no intro:\n\n%s",
-			lang, logContent,
-		)
 
 		pipe, err := buildPipeline()
 		if err != nil {
@@ -73,18 +225,41 @@ var analyzeCmd = &cobra.Command{
 			return
 		}
 
-		sp := spinner.New(fmt.Sprintf("Analyzing %s...", filePath))
+		sp := spinner.New(fmt.Sprintf("Analyzing %s...", name))
 		sp.Start()
 		firstToken := true
 
+		if len(chunks) > 1 {
+			findings, err := mapChunks(ctx, pipe, req, chunks, excerpt, sp)
+			if err != nil {
+				sp.Stop()
+				elapsed := time.Since(start)
+				if isCancelled(ctx, err) {
+					logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Warn("'analyze' command cancelled")
+					metrics.RecordCancelled("analyze", elapsed)
+					fmt.Println(ui.ErrorStyle().Render("⚠️  Cancelled."))
+					return
+				}
+				logger.Log.WithError(err).Error("'analyze' chunk analysis failed")
+				metrics.Record("analyze", elapsed, err.Error())
+				fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+				return
+			}
+			sp.SetLabel("Merging findings...")
+			req.System = guardedSystemPrompt(
+				"You are a sysadmin. The user sends findings extracted from consecutive parts of one or more logs. Merge them into a summary of the critical errors in max 4 bullet points, most important first, no intro.",
+			)
+			req.Prompt = guard.Fence("findings", findings)
+		}
+
 		borderColor := lipgloss.Color(ui.ColorGreen)
-		header := ui.HeaderStyle(ui.ColorGreen).Render("🧠 LOG ANALYSIS › " + filePath)
+		header := ui.HeaderStyle(ui.ColorGreen).Render("🧠 LOG ANALYSIS › " + name)
 
-		response, err := pipe.RunStream(prompt, "analyze", func(token string) {
+		response, err := pipe.RunStream(ctx, req, func(token string) {
 			if firstToken {
 				sp.Stop()
 				firstToken = false
-				fmt.Println(header)
+				fmt.Println(header + metaBadges(req.Meta))
 				fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╭" + strings.Repeat("─", 76) + "╮"))
 				fmt.Print(lipgloss.NewStyle().Foreground(borderColor).Render("│") + "  ")
 			}
@@ -102,6 +277,12 @@ var analyzeCmd = &cobra.Command{
 			if !firstToken {
 				fmt.Println()
 			}
+			if isCancelled(ctx, err) {
+				logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Warn("'analyze' command cancelled")
+				metrics.RecordCancelled("analyze", elapsed)
+				fmt.Println(ui.ErrorStyle().Render("⚠️  Cancelled."))
+				return
+			}
 			logger.Log.WithError(err).Error("'analyze' command failed")
 			metrics.Record("analyze", elapsed, err.Error())
 			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
@@ -111,8 +292,13 @@ var analyzeCmd = &cobra.Command{
 		fmt.Println()
 		fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╰" + strings.Repeat("─", 76) + "╯"))
 
-		logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Info("'analyze' command completed")
+		logger.Log.WithFields(logrus.Fields{
+			"duration_ms": elapsed.Milliseconds(),
+			"cached":      req.Meta.Cached,
+			"redacted":    req.Meta.Redacted,
+		}).Info("'analyze' command completed")
 		metrics.Record("analyze", elapsed, "")
+		warnDownloadExec(response)
 
 		if CopyFlag {
 			if err := clipboard.WriteAll(response); err != nil {
@@ -125,6 +311,170 @@ var analyzeCmd = &cobra.Command{
 	},
 }
 
+// logFilter builds the structured-log filter from --level, --since and
+// --field.
+func logFilter(now time.Time) (logs.Filter, error) {
+	var f logs.Filter
+	if analyzeLevel != "" {
+		level, ok := logs.ParseLevel(analyzeLevel)
+		if !ok {
+			return f, fmt.Errorf("invalid --level %q: use trace, debug, info, warn, error or fatal", analyzeLevel)
+		}
+		f.MinLevel = level
+	}
+	if analyzeSince != "" {
+		since, err := parseSince(analyzeSince, now)
+		if err != nil {
+			return f, err
+		}
+		f.Since = since
+	}
+	for _, s := range analyzeFields {
+		field, err := logs.ParseFieldFilter(s)
+		if err != nil {
+			return f, err
+		}
+		f.Fields = append(f.Fields, field)
+	}
+	return f, nil
+}
+
+// structuredView replaces the structured logs among sources by their
+// compact, filtered view and reports what it did per log. Plain-text logs
+// are kept whole, as the filter needs parsed records. It returns the logs
+// left to analyze and whether any of them was rewritten.
+func structuredView(sources []logs.Source, filter logs.Filter) ([]logs.Source, bool) {
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+	kept := make([]logs.Source, 0, len(sources))
+	structured := false
+	for _, src := range sources {
+		n, ok := logs.Normalize(src.Text, filter)
+		if !ok {
+			if filter.Active() {
+				fmt.Println(dim.Render(fmt.Sprintf("🧾 %s is plain text: --level, --since and --field do not apply to it.", src.Name)))
+			}
+			kept = append(kept, src)
+			continue
+		}
+		logger.Log.WithFields(logrus.Fields{
+			"source":  src.Name,
+			"format":  n.Format.String(),
+			"records": n.Records,
+			"kept":    n.Kept,
+		}).Info("Structured log normalized")
+		if filter.Active() {
+			fmt.Println(dim.Render(fmt.Sprintf("🧾 %s: %s, %d of %d records match the filters.", src.Name, n.Format, n.Kept, n.Records)))
+		} else {
+			fmt.Println(dim.Render(fmt.Sprintf("🧾 %s: %s, %d records sent as a compact view.", src.Name, n.Format, n.Records)))
+		}
+		if n.Kept > 0 {
+			kept = append(kept, logs.Source{Name: src.Name, Text: n.Text})
+			structured = true
+		}
+	}
+	return kept, structured
+}
+
+// mapChunks analyzes each chunk of a log with up to --jobs concurrent
+// requests and returns the findings labelled by line range, ready for the
+// reduce prompt. When excerpt is set the chunks come from logs.Extract and
+// are labelled by part instead, since their own line numbers are not the
+// log's. Progress is reported through sp. If the findings are still too
+// long for one request they are merged in further rounds.
+func mapChunks(ctx context.Context, pipe *pipeline.Pipeline, base pipeline.Request, chunks []logs.Chunk, excerpt bool, sp *spinner.Spinner) (string, error) {
+	req := base
+	req.System = guardedSystemPrompt(
+		"You are a sysadmin. The user sends one part of a larger log. List the errors, warnings and anomalies in it as terse bullet points, quoting the key message. If nothing is notable, reply exactly: none",
+	)
+	label := func(i int, c logs.Chunk) string {
+		if excerpt {
+			return fmt.Sprintf("part %d of %d%s", i+1, len(chunks), sourceSuffix(c))
+		}
+		return fmt.Sprintf("lines %d-%d%s", c.FirstLine, c.LastLine, sourceSuffix(c))
+	}
+	size := logs.ChunkSize(base.Options.NumCtx)
+
+	for round := 1; ; round++ {
+		completed := 0
+		// Chunks run concurrently, so each gets its own Meta; the counts are
+		// merged in done, which Map calls from one goroutine at a time.
+		metas := make([]pipeline.Meta, len(chunks))
+		sp.SetLabel(fmt.Sprintf("Analyzing chunk 1/%d...", len(chunks)))
+		results, err := logs.Map(ctx, chunks, analyzeJobs, func(ctx context.Context, i int, c logs.Chunk) (string, error) {
+			r := req
+			r.Meta = &metas[i]
+			r.Prompt = guard.Fence("findings", c.Text)
+			if round == 1 {
+				r.Prompt = fmt.Sprintf("Log %s:\n%s", label(i, c), guard.Fence("log", c.Text))
+			}
+			return pipe.Run(ctx, r)
+		}, func(i int, result string) {
+			if base.Meta != nil {
+				base.Meta.Redacted += metas[i].Redacted
+			}
+			completed++
+			note := "issues found"
+			if noFindings(result) {
+				note = "nothing notable"
+			}
+			progress := fmt.Sprintf("chunk %d/%d", i+1, len(chunks))
+			if round == 1 && !excerpt {
+				progress += " (" + label(i, chunks[i]) + ")"
+			}
+			sp.Println(fmt.Sprintf("  ✓ %s: %s", progress, note))
+			sp.SetLabel(fmt.Sprintf("Analyzing chunk %d/%d...", min(completed+1, len(chunks)), len(chunks)))
+		})
+		if err != nil {
+			return "", err
+		}
+
+		var b strings.Builder
+		for i, r := range results {
+			if noFindings(r) {
+				continue
+			}
+			fmt.Fprintf(&b, "Findings for %s:\n%s\n\n", label(i, chunks[i]), strings.TrimSpace(r))
+		}
+		findings := b.String()
+		if findings == "" {
+			return "No part of the log contained errors, warnings or anomalies.", nil
+		}
+		next := logs.Split(findings, size)
+		if len(next) == 1 {
+			return findings, nil
+		}
+		logger.Log.WithFields(logrus.Fields{"round": round, "chunks": len(next)}).Info("Findings too long, merging again")
+		req.System = guardedSystemPrompt(
+			"You are a sysadmin. The user sends findings extracted from parts of one or more logs. Merge duplicates and list the distinct errors, warnings and anomalies as terse bullet points with their line ranges.",
+		)
+		chunks = next
+		excerpt = true // label the merged findings by part
+	}
+}
+
+// sourceSuffix names the log a chunk was cut from, if there are several.
+func sourceSuffix(c logs.Chunk) string {
+	if c.Source == "" {
+		return ""
+	}
+	return " of " + c.Source
+}
+
+// noFindings reports whether a chunk's analysis found nothing notable.
+func noFindings(result string) bool {
+	r := strings.ToLower(strings.Trim(strings.TrimSpace(result), ".*_`"))
+	return r == "" || r == "none"
+}
+
 func init() {
+	analyzeCmd.Flags().BoolVar(&analyzeRaw, "raw", false, "Send the log as is instead of only its error lines and stack traces")
+	analyzeCmd.Flags().IntVarP(&analyzeContext, "context", "C", logs.DefaultContext, "Lines of context kept around each error line")
+	analyzeCmd.Flags().BoolVarP(&analyzeFollow, "follow", "f", false, "Watch the log as it grows and diagnose error bursts as they happen")
+	analyzeCmd.Flags().IntVar(&analyzeBurst, "burst", 5, "Error lines within --window that make a burst (with --follow)")
+	analyzeCmd.Flags().DurationVar(&analyzeWindow, "window", 30*time.Second, "Time window for burst detection (with --follow)")
+	analyzeCmd.Flags().StringVar(&analyzeLevel, "level", "", "Only keep structured log records at this level or above (e.g. warn, error)")
+	analyzeCmd.Flags().StringVar(&analyzeSince, "since", "", "Only keep structured log records within this window, e.g. 10m, 2h or 2024-05-01")
+	analyzeCmd.Flags().StringArrayVar(&analyzeFields, "field", nil, "Only keep structured log records with this key=value (repeatable)")
+	analyzeCmd.Flags().IntVarP(&analyzeJobs, "jobs", "j", 1, "Chunks of a large log analyzed concurrently")
 	rootCmd.AddCommand(analyzeCmd)
 }
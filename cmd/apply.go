@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/shell-sage/internal/logger"
+	"github.com/shell-sage/internal/pipeline/middleware/redact"
+	"github.com/shell-sage/internal/runner"
+	"github.com/shell-sage/internal/safety"
+	"github.com/shell-sage/internal/ui"
+	"github.com/sirupsen/logrus"
+)
+
+// dangerConfirmation must be typed in full to run a command the safety
+// classifier flagged.
+const dangerConfirmation = "yes"
+
+// offerCommand shows a suggested command and lets the user run, edit, copy
+// or skip it. Commands are run in shell (the user's shell when empty) only
+// after confirmation; commands flagged by the safety classifier need
+// dangerConfirmation typed out. It returns the command's exit code, or -1
+// if it was not run.
+func offerCommand(ctx context.Context, command, shell string) int {
+	for {
+		fmt.Println()
+		fmt.Println(ui.HeaderStyle(ui.ColorCyan).Render("💡 Suggested command:"))
+		for _, line := range strings.Split(command, "\n") {
+			fmt.Println("   " + line)
+		}
+		risks := safety.Check(command)
+		for _, r := range risks {
+			fmt.Println(ui.ErrorStyle().Render("⚠️  Dangerous: " + r.Reason))
+		}
+
+		fmt.Print("\n[r]un / [e]dit / [c]opy / [q]uit: ")
+		input, err := readLine(ctx)
+		if err != nil {
+			fmt.Println()
+			return -1
+		}
+
+		switch strings.TrimSpace(strings.ToLower(input)) {
+		case "r", "run":
+			if len(risks) > 0 && !confirmDanger(ctx) {
+				fmt.Println("Not run.")
+				continue
+			}
+			return runSuggestion(ctx, command, shell, risks)
+		case "e", "edit":
+			edited, err := editCommand(ctx, command)
+			if err != nil {
+				fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+				continue
+			}
+			if edited != "" {
+				command = edited
+			}
+		case "c", "copy":
+			if err := clipboard.WriteAll(command); err != nil {
+				logger.Log.WithError(err).Warn("Failed to copy to clipboard")
+				fmt.Println(ui.ErrorStyle().Render("❌ Could not copy: " + err.Error()))
+				continue
+			}
+			logger.Log.Info("Suggested command copied to clipboard")
+			fmt.Println("✅ Copied to clipboard!")
+			return -1
+		case "", "q", "quit":
+			return -1
+		default:
+			fmt.Println("Please answer r, e, c or q.")
+		}
+	}
+}
+
+// confirmDanger asks the user to type dangerConfirmation in full.
+func confirmDanger(ctx context.Context) bool {
+	fmt.Printf("This command is flagged as dangerous. Type %q to run it anyway: ", dangerConfirmation)
+	input, err := readLine(ctx)
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+	return strings.TrimSpace(input) == dangerConfirmation
+}
+
+// runSuggestion executes command in shell (the user's shell when empty)
+// with the terminal attached and reports its exit status, or -1 if ctx was
+// cancelled.
+func runSuggestion(ctx context.Context, command, shell string, risks []safety.Risk) int {
+	rules := make([]string, 0, len(risks))
+	for _, r := range risks {
+		rules = append(rules, r.Rule)
+	}
+	logger.Log.WithFields(logrus.Fields{
+		"command": redact.String(command),
+		"risks":   strings.Join(rules, ","),
+	}).Info("Running suggested command")
+
+	fmt.Println()
+	res, err := runner.Run(ctx, runner.ShellArgsFor(shell, command), runner.Options{})
+	if err != nil {
+		fmt.Println(ui.ErrorStyle().Render("\n⚠️  Cancelled."))
+		return -1 // Execute exits with the signal's status
+	}
+	if res.Failed() {
+		fmt.Println(ui.ErrorStyle().Render(fmt.Sprintf("\n❌ Exited with status %d.", res.ExitCode)))
+	} else {
+		fmt.Println("\n✅ Done.")
+	}
+	return res.ExitCode
+}
+
+// editCommand lets the user change command, in $VISUAL/$EDITOR when set or
+// on a prompt line otherwise. An empty result keeps the command unchanged.
+func editCommand(ctx context.Context, command string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		fmt.Println("Enter the new command (empty keeps it unchanged):")
+		fmt.Print("> ")
+		line, err := readLine(ctx)
+		if err != nil {
+			fmt.Println()
+			return "", nil
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	f, err := os.CreateTemp("", "ssage-cmd-*.sh")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(command + "\n"); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	// EDITOR may carry arguments (e.g. "code --wait"), so run it via the shell.
+	argv := runner.ShellArgs(editor + " " + shellQuoteArg(f.Name()))
+	c := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// shellQuoteArg quotes s as a single shell word.
+func shellQuoteArg(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/shell-sage/internal/pipeline/middleware/cache"
+	"github.com/shell-sage/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cacheListCommand string
+	cacheClearYes    bool
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and manage the response cache",
+	Long: `Inspect and manage cached AI answers stored in ~/.ssage_cache.
+
+The cache is bounded by cache.max_entries and cache.max_size_mb (see
+'ssage config set'); least recently used entries are evicted first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheStatsCmd.Run(cmd, args)
+	},
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache size and hit/miss ratio per command",
+	Run: func(cmd *cobra.Command, args []string) {
+		store := cache.Open(cache.Dir())
+		stats, err := store.Stats()
+		if err != nil {
+			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+			return
+		}
+		if len(stats) == 0 {
+			fmt.Println(ui.ErrorStyle().Render("⚠️  Cache is empty."))
+			return
+		}
+
+		names := make([]string, 0, len(stats))
+		var total cache.CommandStats
+		for name, s := range stats {
+			names = append(names, name)
+			total.Entries += s.Entries
+			total.Bytes += s.Bytes
+			total.Hits += s.Hits
+			total.Misses += s.Misses
+		}
+		sort.Strings(names)
+
+		titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(ui.ColorCyan)).MarginBottom(1)
+		labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888")).Width(16)
+		valueStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(ui.ColorText))
+		divider := lipgloss.NewStyle().Foreground(lipgloss.Color("#333333")).Render(strings.Repeat("─", 40))
+
+		_, limits := cachePolicy()
+		limits = limits.WithDefaults()
+
+		fmt.Println(titleStyle.Render("🗄️  Shell Sage — Cache"))
+		fmt.Printf("  %s %s\n", labelStyle.Render("Directory:"), valueStyle.Render(store.Path()))
+		fmt.Printf("  %s %s / %d\n", labelStyle.Render("Entries:"), valueStyle.Render(fmt.Sprintf("%d", total.Entries)), limits.MaxEntries)
+		fmt.Printf("  %s %s / %s\n", labelStyle.Render("Disk:"), valueStyle.Render(formatBytes(total.Bytes)), formatBytes(limits.MaxBytes))
+		fmt.Printf("  %s %s\n", labelStyle.Render("Hit ratio:"), valueStyle.Render(hitRatio(total.Counter)))
+		fmt.Println(divider)
+
+		for _, name := range names {
+			s := stats[name]
+			label := name
+			if label == "" {
+				label = "(unknown)"
+			}
+			fmt.Printf("\n%s\n", lipgloss.NewStyle().Bold(true).Render("▸ "+label))
+			fmt.Printf("  %s %d (%s)\n", labelStyle.Render("Entries:"), s.Entries, formatBytes(s.Bytes))
+			fmt.Printf("  %s %s  (%d hits / %d misses)\n", labelStyle.Render("Hit ratio:"), hitRatio(s.Counter), s.Hits, s.Misses)
+			if s.Entries > 0 {
+				fmt.Printf("  %s %s\n", labelStyle.Render("Oldest:"), s.Oldest.Format("Jan 2 15:04"))
+				fmt.Printf("  %s %s\n", labelStyle.Render("Newest:"), s.Newest.Format("Jan 2 15:04"))
+			}
+		}
+		fmt.Println(divider)
+	},
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached entries, most recently used first",
+	Run: func(cmd *cobra.Command, args []string) {
+		entries, err := cache.Open(cache.Dir()).List()
+		if err != nil {
+			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+			return
+		}
+		dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+		fmt.Println(dim.Render(fmt.Sprintf("%-12s  %-8s  %-16s  %-9s  %-9s  %7s  %s", "KEY", "COMMAND", "MODEL", "CREATED", "USED", "SIZE", "PROMPT")))
+		shown := 0
+		for _, e := range entries {
+			if cacheListCommand != "" && e.Command != cacheListCommand {
+				continue
+			}
+			shown++
+			fmt.Printf("%-12s  %-8s  %-16s  %-9s  %-9s  %7s  %s\n",
+				e.Key[:12],
+				truncate(e.Command, 8),
+				truncate(e.Provider+"/"+e.Model, 16),
+				humanAge(e.CreatedAt),
+				humanAge(e.AccessedAt),
+				formatBytes(e.Size),
+				truncate(oneLine(e.Prompt), 40),
+			)
+		}
+		if shown == 0 {
+			fmt.Println(ui.ErrorStyle().Render("⚠️  No cached entries."))
+		}
+	},
+}
+
+var cacheShowCmd = &cobra.Command{
+	Use:   "show <key>",
+	Short: "Show a cached entry (a unique key prefix is enough)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := cache.Open(cache.Dir())
+		key, err := store.Resolve(args[0])
+		if err != nil {
+			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+			return
+		}
+		e, err := store.Get(key)
+		if err != nil {
+			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+			return
+		}
+		label := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888")).Width(12)
+		fmt.Printf("%s %s\n", label.Render("Key:"), e.Key)
+		fmt.Printf("%s %s\n", label.Render("Command:"), e.Command)
+		fmt.Printf("%s %s / %s\n", label.Render("Backend:"), e.Provider, e.Model)
+		fmt.Printf("%s %s\n", label.Render("Created:"), e.CreatedAt.Format(time.RFC1123))
+		fmt.Printf("%s %s (%d hits)\n", label.Render("Last used:"), e.AccessedAt.Format(time.RFC1123), e.Hits)
+		fmt.Printf("%s %s\n", label.Render("Size:"), formatBytes(e.Size))
+		fmt.Println(ui.HeaderStyle(ui.ColorCyan).Render("PROMPT"))
+		fmt.Println(e.Prompt)
+		fmt.Println(ui.HeaderStyle(ui.ColorGreen).Render("RESPONSE"))
+		fmt.Println(e.Response)
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Delete every cached entry",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cacheClearYes {
+			fmt.Print("🗑️  Delete all cached answers? [y/N]: ")
+			input, err := readLine(cmd.Context())
+			if err != nil || strings.TrimSpace(strings.ToLower(input)) != "y" {
+				return
+			}
+		}
+		n, err := cache.Open(cache.Dir()).Clear()
+		if err != nil {
+			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+			return
+		}
+		fmt.Printf("✅ Removed %d cached entries.\n", n)
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired entries and enforce the size limits",
+	Run: func(cmd *cobra.Command, args []string) {
+		ttl, limits := cachePolicy()
+		n, freed, err := cache.Open(cache.Dir()).Prune(ttl, limits)
+		if err != nil {
+			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+			return
+		}
+		fmt.Printf("✅ Pruned %d entries, freed %s.\n", n, formatBytes(freed))
+	},
+}
+
+// hitRatio renders hits/(hits+misses) as a percentage.
+func hitRatio(c cache.Counter) string {
+	total := c.Hits + c.Misses
+	if total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%d%%", c.Hits*100/total)
+}
+
+// formatBytes renders a byte count using binary units.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// humanAge renders how long ago t was, e.g. "5m ago", "3d ago".
+func humanAge(t time.Time) string {
+	d := time.Since(t)
+	if d < 0 {
+		d = 0 // clock skew between machines sharing a home directory
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+// oneLine collapses whitespace so multi-line text fits on a single row.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd, cacheListCmd, cacheShowCmd, cacheClearCmd, cachePruneCmd)
+	cacheListCmd.Flags().StringVar(&cacheListCommand, "command", "", "Only list entries created by this command (e.g. explain)")
+	cacheClearCmd.Flags().BoolVarP(&cacheClearYes, "yes", "y", false, "Do not ask for confirmation")
+}
@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/shell-sage/internal/config"
+	"github.com/shell-sage/internal/history"
 
 	"github.com/spf13/cobra"
 )
@@ -23,13 +29,68 @@ var configCmd = &cobra.Command{
 		fmt.Printf("Model: %s\n", cfg.Model)
 		fmt.Printf("Language: %s\n", cfg.Lang)
 		fmt.Printf("Provider: %s\n", cfg.Provider)
+		if cfg.Cache != (config.CacheConfig{}) {
+			fmt.Printf("Cache: ttl=%s, max_entries=%d, max_size_mb=%d\n", cfg.Cache.TTL, cfg.Cache.MaxEntries, cfg.Cache.MaxSizeMB)
+		}
+		if cfg.Fix != (config.FixConfig{}) {
+			fmt.Printf("Fix: timeout=%s, max_output_kb=%d\n", cfg.Fix.Timeout, cfg.Fix.MaxOutputKB)
+		}
+		if cfg.History.Source != "" {
+			fmt.Printf("History source: %s\n", cfg.History.Source)
+		}
+		if cfg.History.Ignore != nil {
+			fmt.Printf("History ignore: %s\n", strings.Join(*cfg.History.Ignore, ","))
+		}
+		if len(cfg.History.IgnorePatterns) > 0 {
+			fmt.Printf("History ignore patterns: %q\n", cfg.History.IgnorePatterns)
+		}
+		printOptions("Options", cfg.Options)
+		names := make([]string, 0, len(cfg.CommandOptions))
+		for name := range cfg.CommandOptions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			printOptions("Options ("+name+")", cfg.CommandOptions[name])
+		}
 	},
 }
 
+// printOptions prints the generation options that are set, if any.
+func printOptions(label string, o config.GenerationOptions) {
+	var parts []string
+	if o.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("temperature=%g", *o.Temperature))
+	}
+	if o.NumCtx > 0 {
+		parts = append(parts, fmt.Sprintf("num_ctx=%d", o.NumCtx))
+	}
+	if o.Seed != nil {
+		parts = append(parts, fmt.Sprintf("seed=%d", *o.Seed))
+	}
+	if len(o.Stop) > 0 {
+		parts = append(parts, fmt.Sprintf("stop=%q", o.Stop))
+	}
+	if o.KeepAlive != "" {
+		parts = append(parts, "keep_alive="+o.KeepAlive)
+	}
+	if len(parts) > 0 {
+		fmt.Printf("%s: %s\n", label, strings.Join(parts, ", "))
+	}
+}
+
 var setConfigCmd = &cobra.Command{
 	Use:   "set [key] [value]",
 	Short: "Set a configuration value",
-	Args:  cobra.ExactArgs(2),
+	Long: `Set a configuration value.
+
+Generation options (temperature, num_ctx, seed, stop, keep_alive) apply to
+every command, or to a single command when prefixed with its name:
+
+  ssage config set temperature 0
+  ssage config set analyze.num_ctx 8192
+  ssage config set stop "###,END"`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		cfg, err := config.Load()
 		if err != nil {
@@ -47,9 +108,54 @@ var setConfigCmd = &cobra.Command{
 			cfg.Lang = value
 		case "provider":
 			cfg.Provider = value
+		case "history.source":
+			if _, ok := history.Lookup(value); !ok && value != "" {
+				fmt.Printf("Invalid value for %s: unknown source %q (available: %s)\n", key, value, strings.Join(history.Sources(), ", "))
+				return
+			}
+			cfg.History.Source = value
+		case "history.ignore":
+			ignore := []string{}
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					ignore = append(ignore, name)
+				}
+			}
+			cfg.History.Ignore = &ignore
+		case "cache.ttl", "fix.timeout":
+			if _, err := time.ParseDuration(value); err != nil {
+				fmt.Printf("Invalid value for %s: %v\n", key, err)
+				return
+			}
+			if key == "cache.ttl" {
+				cfg.Cache.TTL = value
+			} else {
+				cfg.Fix.Timeout = value
+			}
+		case "cache.max_entries", "cache.max_size_mb", "fix.max_output_kb":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				fmt.Printf("Invalid value for %s: must be a positive integer\n", key)
+				return
+			}
+			switch key {
+			case "cache.max_entries":
+				cfg.Cache.MaxEntries = n
+			case "cache.max_size_mb":
+				cfg.Cache.MaxSizeMB = n
+			default:
+				cfg.Fix.MaxOutputKB = n
+			}
 		default:
-			fmt.Printf("Unknown config key: %s (available: model, lang, provider)\n", key)
-			return
+			if err := setOption(cfg, key, value); err != nil {
+				if errors.Is(err, config.ErrUnknownOption) {
+					fmt.Printf("Unknown config key: %s (available: model, lang, provider, cache.ttl, cache.max_entries, cache.max_size_mb, fix.timeout, fix.max_output_kb, history.source, history.ignore, [command.]%s)\n",
+						key, strings.Join(config.OptionKeys, "|"))
+				} else {
+					fmt.Printf("Invalid value for %s: %v\n", key, err)
+				}
+				return
+			}
 		}
 
 		if err := cfg.Save(); err != nil {
@@ -61,6 +167,24 @@ var setConfigCmd = &cobra.Command{
 	},
 }
 
+// setOption assigns a generation option. key is either a bare option name
+// (global default) or "<command>.<option>" for a per-command override.
+func setOption(cfg *config.Config, key, value string) error {
+	command, option, scoped := strings.Cut(key, ".")
+	if !scoped {
+		return cfg.Options.Set(key, value)
+	}
+	if cfg.CommandOptions == nil {
+		cfg.CommandOptions = make(map[string]config.GenerationOptions)
+	}
+	o := cfg.CommandOptions[command]
+	if err := o.Set(option, value); err != nil {
+		return err
+	}
+	cfg.CommandOptions[command] = o
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(setConfigCmd)
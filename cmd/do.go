@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/shell-sage/internal/logger"
+	"github.com/shell-sage/internal/metrics"
+	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/spinner"
+	"github.com/shell-sage/internal/suggest"
+	"github.com/shell-sage/internal/ui"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doShell        string
+	doAlternatives int
+)
+
+// maxAlternatives bounds --alternatives to keep answers short.
+const maxAlternatives = 5
+
+var doCmd = &cobra.Command{
+	Use:   "do <task>",
+	Short: "Turn a task described in plain words into a shell command",
+	Long: `Describe what you want to do and get a command for it, written for your
+OS and shell, with a short explanation. As with 'fix', the command can be
+run, edited, copied or skipped, and dangerous commands must be confirmed.
+
+  ssage do "find all files over 100MB modified this week"
+  ssage do --shell pwsh "list listening ports with their process"
+  ssage do --alternatives 2 "count lines of Go code per directory"
+
+ssage exits with the command's exit code when you run it.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if doAlternatives < 0 || doAlternatives > maxAlternatives {
+			fmt.Println(ui.ErrorStyle().Render(fmt.Sprintf("❌ --alternatives must be between 0 and %d.", maxAlternatives)))
+			os.Exit(2)
+		}
+		if code := doTask(cmd.Context(), strings.Join(args, " ")); code > 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+// doTask asks for a command performing task, renders the answer and offers
+// to run it. It returns the command's exit code if the user ran it, or -1.
+func doTask(ctx context.Context, task string) int {
+	start := time.Now()
+	logger.Log.WithFields(logrus.Fields{
+		"shell":        doShell,
+		"alternatives": doAlternatives,
+	}).Info("Starting 'do' command")
+
+	target := "the OS and shell in the system context"
+	if doShell != "" {
+		target = fmt.Sprintf("the %s shell on the OS in the system context (not the shell named there)", doShell)
+	}
+	instructions := fmt.Sprintf("You are a shell expert. Write a command for %s that does the task the user describes. Prefer standard tools that ship with the OS. Explain what it does in max 2 short bullet points, no intro. End your answer with the command in a fenced code block (```sh ... ```). Put nothing after the code block. If the task cannot be done with a command, say so and do not include a code block.", target)
+	if doAlternatives > 0 {
+		instructions = fmt.Sprintf("You are a shell expert. Write %d different commands for %s that do the task the user describes, best first, e.g. using different tools. Number them. For each, give a one-line explanation followed by the command in its own fenced code block (```sh ... ```). No intro, nothing after the last code block.", doAlternatives+1, target)
+	}
+	req := pipeline.Request{
+		System:  systemPrompt(instructions),
+		Prompt:  task,
+		Command: "do",
+		Options: generationOptions("do"),
+		Meta:    &pipeline.Meta{},
+	}
+
+	pipe, err := buildPipeline()
+	if err != nil {
+		elapsed := time.Since(start)
+		logger.Log.WithError(err).Error("'do' failed to build pipeline")
+		metrics.Record("do", elapsed, err.Error())
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return -1
+	}
+
+	sp := spinner.New("Writing the command...")
+	sp.Start()
+	firstToken := true
+
+	borderColor := lipgloss.Color(ui.ColorCyan)
+	header := ui.HeaderStyle(ui.ColorCyan).Render("🪄 DO › " + truncate(task, 60))
+
+	response, err := pipe.RunStream(ctx, req, func(token string) {
+		if firstToken {
+			sp.Stop()
+			firstToken = false
+			fmt.Println(header + metaBadges(req.Meta))
+			fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╭" + strings.Repeat("─", 76) + "╮"))
+			fmt.Print(lipgloss.NewStyle().Foreground(borderColor).Render("│") + "  ")
+		}
+		formatted := strings.ReplaceAll(token, "\n", "\n"+lipgloss.NewStyle().Foreground(borderColor).Render("│")+"  ")
+		fmt.Print(formatted)
+	})
+
+	if firstToken {
+		sp.Stop()
+	}
+
+	elapsed := time.Since(start)
+
+	if err != nil {
+		if !firstToken {
+			fmt.Println()
+		}
+		if isCancelled(ctx, err) {
+			logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Warn("'do' command cancelled")
+			metrics.RecordCancelled("do", elapsed)
+			fmt.Println(ui.ErrorStyle().Render("⚠️  Cancelled."))
+			return -1
+		}
+		logger.Log.WithError(err).Error("'do' command failed")
+		metrics.Record("do", elapsed, err.Error())
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return -1
+	}
+
+	fmt.Println()
+	fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╰" + strings.Repeat("─", 76) + "╯"))
+
+	commands := suggest.Commands(response)
+	logger.Log.WithFields(logrus.Fields{
+		"duration_ms": elapsed.Milliseconds(),
+		"cached":      req.Meta.Cached,
+		"redacted":    req.Meta.Redacted,
+		"commands":    len(commands),
+	}).Info("'do' command completed")
+	metrics.Record("do", elapsed, "")
+
+	if len(commands) == 0 {
+		return -1
+	}
+	command := commands[0]
+	if len(commands) > 1 {
+		var ok bool
+		if command, ok = pickCommand(ctx, commands); !ok {
+			return -1
+		}
+	}
+
+	if CopyFlag {
+		if err := clipboard.WriteAll(command); err != nil {
+			logger.Log.WithError(err).Warn("Failed to copy to clipboard")
+			fmt.Println(ui.ErrorStyle().Render("\n❌ Could not copy: " + err.Error()))
+		} else {
+			fmt.Println("\n✅ Copied to clipboard!")
+		}
+		return -1
+	}
+
+	shell := ""
+	if doShell != "" {
+		if path, err := exec.LookPath(doShell); err == nil {
+			shell = path
+		} else {
+			fmt.Println(ui.ErrorStyle().Render(fmt.Sprintf("\n⚠️  %s is not installed here: copy the command to run it elsewhere.", doShell)))
+			shell = doShell
+		}
+	}
+	return offerCommand(ctx, command, shell)
+}
+
+// pickCommand asks which of several suggested commands to use. It returns
+// false if the user quits.
+func pickCommand(ctx context.Context, commands []string) (string, bool) {
+	for {
+		fmt.Printf("\nPick a command [1-%d] or [q]uit: ", len(commands))
+		input, err := readLine(ctx)
+		if err != nil {
+			fmt.Println()
+			return "", false
+		}
+		input = strings.TrimSpace(strings.ToLower(input))
+		switch input {
+		case "", "q", "quit":
+			return "", false
+		}
+		if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(commands) {
+			return commands[n-1], true
+		}
+		fmt.Printf("Please answer a number from 1 to %d, or q.\n", len(commands))
+	}
+}
+
+func init() {
+	doCmd.Flags().StringVar(&doShell, "shell", "", "Write the command for this shell instead of yours (e.g. bash, zsh, fish, pwsh, nu)")
+	doCmd.Flags().IntVarP(&doAlternatives, "alternatives", "n", 0, "Also suggest this many alternative commands to pick from")
+	rootCmd.AddCommand(doCmd)
+}
@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/shell-sage/internal/guard"
 	"github.com/shell-sage/internal/logger"
 	"github.com/shell-sage/internal/metrics"
+	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/pipeline/middleware/redact"
+	"github.com/shell-sage/internal/safety"
 	"github.com/shell-sage/internal/spinner"
 	"github.com/shell-sage/internal/ui"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -19,83 +25,106 @@ var explainCmd = &cobra.Command{
 	Short: "Explain a shell command",
 	Args:  cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		start := time.Now()
-		commandToExplain := strings.Join(args, " ")
+		explainCommand(cmd.Context(), strings.Join(args, " "))
+	},
+}
 
-		logger.Log.WithField("command", commandToExplain).Info("Starting 'explain' command")
+// explainCommand streams an explanation of commandToExplain. It is shared by
+// `explain` and `history <index> --explain`.
+func explainCommand(ctx context.Context, commandToExplain string) {
+	start := time.Now()
 
-		lang := "English"
-		if LangFlag != "" {
-			lang = LangFlag
-		}
-		prompt := fmt.Sprintf(
-			"IMPORTANT: You MUST respond ONLY in %s. Do not use any other language.\nExplain this shell command in max 3 bullet points. Be extremely concise, no intro, no extra text: '%s'",
-			lang, commandToExplain,
-		)
-
-		pipe, err := buildPipeline()
-		if err != nil {
-			elapsed := time.Since(start)
-			logger.Log.WithError(err).Error("'explain' failed to build pipeline")
-			metrics.Record("explain", elapsed, err.Error())
-			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
-			return
-		}
+	logger.Log.WithField("command", redact.String(commandToExplain)).Info("Starting 'explain' command")
 
-		// Show spinner until first token arrives
-		sp := spinner.New("Consulting the AI sage...")
-		sp.Start()
-		firstToken := true
-
-		borderColor := lipgloss.Color(ui.ColorCyan)
-		header := ui.HeaderStyle(ui.ColorCyan).Render("⚡ EXPLAIN › " + commandToExplain)
-
-		response, err := pipe.RunStream(prompt, "explain", func(token string) {
-			if firstToken {
-				sp.Stop()
-				firstToken = false
-				// Print header and open border
-				fmt.Println(header)
-				fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╭" + strings.Repeat("─", 76) + "╮"))
-				fmt.Print(lipgloss.NewStyle().Foreground(borderColor).Render("│") + "  ")
-			}
-			// Print each token, handle newlines to keep box formatting
-			formatted := strings.ReplaceAll(token, "\n", "\n"+lipgloss.NewStyle().Foreground(borderColor).Render("│")+"  ")
-			fmt.Print(formatted)
-		})
+	req := pipeline.Request{
+		System: guardedSystemPrompt(
+			"You are a shell expert. Explain the shell command the user gives you in max 3 bullet points. Be extremely concise, no intro, no extra text.",
+		),
+		Prompt:  guard.Fence("command", commandToExplain),
+		Command: "explain",
+		Options: generationOptions("explain"),
+		Meta:    &pipeline.Meta{},
+	}
 
-		if firstToken {
-			sp.Stop() // In case we never got a token
-		}
+	warnInjection("The command", commandToExplain, false)
 
+	pipe, err := buildPipeline()
+	if err != nil {
 		elapsed := time.Since(start)
+		logger.Log.WithError(err).Error("'explain' failed to build pipeline")
+		metrics.Record("explain", elapsed, err.Error())
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return
+	}
 
-		if err != nil {
-			if !firstToken {
-				fmt.Println() // Clean newline after partial output
-			}
-			logger.Log.WithError(err).WithField("duration_ms", elapsed.Milliseconds()).Error("'explain' command failed")
-			metrics.Record("explain", elapsed, err.Error())
-			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
-			return
+	// Show spinner until first token arrives
+	sp := spinner.New("Consulting the AI sage...")
+	sp.Start()
+	firstToken := true
+
+	borderColor := lipgloss.Color(ui.ColorCyan)
+	header := ui.HeaderStyle(ui.ColorCyan).Render("⚡ EXPLAIN › " + commandToExplain)
+
+	response, err := pipe.RunStream(ctx, req, func(token string) {
+		if firstToken {
+			sp.Stop()
+			firstToken = false
+			// Print header and open border
+			fmt.Println(header + metaBadges(req.Meta))
+			fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╭" + strings.Repeat("─", 76) + "╮"))
+			fmt.Print(lipgloss.NewStyle().Foreground(borderColor).Render("│") + "  ")
 		}
+		// Print each token, handle newlines to keep box formatting
+		formatted := strings.ReplaceAll(token, "\n", "\n"+lipgloss.NewStyle().Foreground(borderColor).Render("│")+"  ")
+		fmt.Print(formatted)
+	})
 
-		// Close the box
-		fmt.Println()
-		fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╰" + strings.Repeat("─", 76) + "╯"))
+	if firstToken {
+		sp.Stop() // In case we never got a token
+	}
 
-		logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Info("'explain' command completed")
-		metrics.Record("explain", elapsed, "")
+	elapsed := time.Since(start)
 
-		if CopyFlag {
-			if err := clipboard.WriteAll(response); err != nil {
-				logger.Log.WithError(err).Warn("Failed to copy to clipboard")
-				fmt.Println(ui.ErrorStyle().Render("\n❌ Could not copy: " + err.Error()))
-			} else {
-				fmt.Println("\n✅ Copied to clipboard!")
-			}
+	if err != nil {
+		if !firstToken {
+			fmt.Println() // Clean newline after partial output
 		}
-	},
+		if isCancelled(ctx, err) {
+			logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Warn("'explain' command cancelled")
+			metrics.RecordCancelled("explain", elapsed)
+			fmt.Println(ui.ErrorStyle().Render("⚠️  Cancelled."))
+			return
+		}
+		logger.Log.WithError(err).WithField("duration_ms", elapsed.Milliseconds()).Error("'explain' command failed")
+		metrics.Record("explain", elapsed, err.Error())
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return
+	}
+
+	// Close the box
+	fmt.Println()
+	fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╰" + strings.Repeat("─", 76) + "╯"))
+
+	logger.Log.WithFields(logrus.Fields{
+		"duration_ms": elapsed.Milliseconds(),
+		"cached":      req.Meta.Cached,
+		"redacted":    req.Meta.Redacted,
+	}).Info("'explain' command completed")
+	metrics.Record("explain", elapsed, "")
+	// Explaining a `curl … | sh` naturally mentions it; only an answer
+	// that brings one up on its own is suspicious.
+	if !safety.DownloadExec(commandToExplain) {
+		warnDownloadExec(response)
+	}
+
+	if CopyFlag {
+		if err := clipboard.WriteAll(response); err != nil {
+			logger.Log.WithError(err).Warn("Failed to copy to clipboard")
+			fmt.Println(ui.ErrorStyle().Render("\n❌ Could not copy: " + err.Error()))
+		} else {
+			fmt.Println("\n✅ Copied to clipboard!")
+		}
+	}
 }
 
 func init() {
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -9,123 +9,377 @@ import (
 
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/shell-sage/internal/config"
+	"github.com/shell-sage/internal/guard"
 	"github.com/shell-sage/internal/history"
+	"github.com/shell-sage/internal/journal"
 	"github.com/shell-sage/internal/logger"
 	"github.com/shell-sage/internal/metrics"
+	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/pipeline/middleware/redact"
+	"github.com/shell-sage/internal/runner"
 	"github.com/shell-sage/internal/spinner"
+	"github.com/shell-sage/internal/suggest"
 	"github.com/shell-sage/internal/ui"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+var (
+	fixTimeout   time.Duration
+	fixMaxOutput int
+)
+
 var fixCmd = &cobra.Command{
-	Use:   "fix",
+	Use:   "fix [-- command [args...]]",
 	Short: "Analyze recent shell history and suggest a fix for the last error",
+	Long: `Suggest a fix for the last failed command.
+
+With the shell hook installed (see 'ssage init'), fix targets the most recent
+command that exited non-zero and includes its exit code, working directory
+and captured error output. Otherwise it infers the failure from the last
+commands in your shell history.
+
+Given a command after '--', fix runs it, streams its output to the terminal
+and, if it fails, sends the command and the tail of its output for a fix.
+ssage then exits with the command's exit code, so it can be used in scripts:
+
+  ssage fix -- make build
+  ssage fix --timeout 5m -- "npm ci && npm test"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// Only run what follows '--', so that a stray word such as
+		// 'ssage fix it' is not executed.
+		if len(args) > 0 && cmd.ArgsLenAtDash() != 0 {
+			return fmt.Errorf("put the command to run after '--', e.g. 'ssage fix -- %s'", strings.Join(args, " "))
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		start := time.Now()
-		logger.Log.Info("Starting 'fix' command")
+		ctx := cmd.Context()
+		if len(args) == 0 {
+			if code := suggestFix(ctx, nil); code > 0 {
+				os.Exit(code)
+			}
+			return
+		}
+
+		timeout, maxOutput := fixLimits(cmd)
+		logger.Log.WithFields(logrus.Fields{
+			"command":    redact.String(strings.Join(args, " ")),
+			"timeout":    timeout.String(),
+			"max_output": maxOutput,
+		}).Info("Starting 'fix' command run")
 
-		commands, err := history.GetRecentCommands(10)
+		res, err := runner.Run(ctx, args, runner.Options{Timeout: timeout, MaxOutput: maxOutput})
 		if err != nil {
+			logger.Log.WithField("duration_ms", res.Duration.Milliseconds()).Warn("'fix' command run cancelled")
+			metrics.RecordCancelled("fix", res.Duration)
+			fmt.Println(ui.ErrorStyle().Render("\n⚠️  Cancelled."))
+			return // Execute exits with the signal's status
+		}
+		logger.Log.WithFields(logrus.Fields{
+			"exit_code":   res.ExitCode,
+			"duration_ms": res.Duration.Milliseconds(),
+			"timed_out":   res.TimedOut,
+		}).Info("'fix' command run finished")
+
+		if res.Failed() {
+			fmt.Println()
+			if res.TimedOut {
+				fmt.Println(ui.ErrorStyle().Render(fmt.Sprintf("⏱️  Command timed out after %s.", timeout)))
+			}
+			// If the user ran the suggested fix, its status is what matters.
+			if code := suggestFix(ctx, res); code >= 0 {
+				os.Exit(code)
+			}
+		}
+		os.Exit(res.ExitCode)
+	},
+}
+
+// fixLimits returns the timeout and output cap for `fix -- <command>`:
+// flags when given, then the fix.* config keys, then the defaults.
+func fixLimits(cmd *cobra.Command) (time.Duration, int) {
+	timeout := fixTimeout
+	maxOutput := fixMaxOutput << 10
+	cfg, err := config.Load()
+	if err != nil {
+		return timeout, maxOutput
+	}
+	if !cmd.Flags().Changed("timeout") {
+		if d, err := time.ParseDuration(cfg.Fix.Timeout); err == nil && d > 0 {
+			timeout = d
+		}
+	}
+	if !cmd.Flags().Changed("max-output") && cfg.Fix.MaxOutputKB > 0 {
+		maxOutput = cfg.Fix.MaxOutputKB << 10
+	}
+	return timeout, maxOutput
+}
+
+// suggestFix asks the model for a fix, renders it and offers to run the
+// corrected command. run is a failed command ssage just executed itself;
+// when nil, the failure is taken from the command journal if the last
+// command recorded there failed, or else inferred from shell history. It
+// returns the exit code of the suggested command if the user ran it, or -1.
+func suggestFix(ctx context.Context, run *runner.Result) int {
+	start := time.Now()
+	logger.Log.Info("Starting 'fix' command")
+
+	var (
+		failure  *journal.Entry
+		commands []history.Entry
+	)
+	if run == nil {
+		var err error
+		failure, err = journal.LastFailure(func(e journal.Entry) bool { return history.IsSelf(e.Command) })
+		if err != nil {
+			logger.Log.WithError(err).Warn("Failed to read command journal")
+		}
+
+		commands, err = history.Recent(10)
+		if err != nil && failure == nil {
 			elapsed := time.Since(start)
 			logger.Log.WithError(err).Error("Failed to read shell history")
 			metrics.Record("fix", elapsed, err.Error())
 			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
-			return
+			return -1
 		}
 
-		if len(commands) == 0 {
+		if len(commands) == 0 && failure == nil {
 			fmt.Println(ui.ErrorStyle().Render("⚠️  No recent commands found in history."))
-			return
+			return -1
 		}
 
-		logger.Log.WithField("commands_found", len(commands)).Info("Shell history read")
+		logger.Log.WithFields(logrus.Fields{
+			"commands_found": len(commands),
+			"journal_hit":    failure != nil,
+		}).Info("Shell history read")
+	}
 
-		lang := "English"
-		if LangFlag != "" {
-			lang = LangFlag
-		}
-		prompt := fmt.Sprintf(
-			"IMPORTANT: You MUST respond ONLY in %s. Do not use any other language.\nYou are a shell expert. Given these recent commands, identify if the last one likely failed and suggest a concise fix in max 3 bullet points. Commands: %s",
-			lang, strings.Join(commands, " | "),
+	req := pipeline.Request{
+		System: guardedSystemPrompt(
+			"You are a shell expert. Given the user's recent commands, identify if the last one likely failed and explain the fix in max 3 short bullet points. " + suggest.Format,
+		),
+		Prompt:  "Recent commands, oldest first:\n" + describeHistory(commands),
+		Command: "fix",
+		Options: generationOptions("fix"),
+		Meta:    &pipeline.Meta{},
+	}
+	kind := "shell history"
+	title := "🔧 FIX SUGGESTION"
+	spinnerText := "Scanning history for errors..."
+	switch {
+	case run != nil:
+		req.System = guardedSystemPrompt(
+			"You are a shell expert. The user's command failed. Using its exit code and output, explain the likely cause and the fix in max 3 short bullet points. " + suggest.Format,
 		)
+		req.Prompt = describeRun(run)
+		kind = "command output"
+		title += " › " + truncate(run.Command, 60)
+		spinnerText = "Analyzing failure..."
+	case failure != nil:
+		req.System = guardedSystemPrompt(
+			"You are a shell expert. The user's command failed. Using its exit code and error output, explain the likely cause and the fix in max 3 short bullet points. " + suggest.Format,
+		)
+		req.Prompt = describeFailure(failure, commands)
+		kind = "command output"
+		title += " › " + truncate(failure.Command, 60)
+	}
+	return streamFix(ctx, start, req, kind, title, spinnerText)
+}
 
-		pipe, err := buildPipeline()
-		if err != nil {
-			elapsed := time.Since(start)
-			logger.Log.WithError(err).Error("'fix' failed to build pipeline")
-			metrics.Record("fix", elapsed, err.Error())
-			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
-			return
-		}
+// streamFix sends a fix request, renders the answer and offers to run the
+// command it suggests. The request's prompt is untrusted text describing
+// the failure: it is checked for injected instructions and then fenced as
+// kind (see guard.Fence), and the answer is checked for download-and-execute
+// commands. It returns the exit code of the suggested command if the user
+// ran it, or -1.
+func streamFix(ctx context.Context, start time.Time, req pipeline.Request, kind, title, spinnerText string) int {
+	pipe, err := buildPipeline()
+	if err != nil {
+		elapsed := time.Since(start)
+		logger.Log.WithError(err).Error("'fix' failed to build pipeline")
+		metrics.Record("fix", elapsed, err.Error())
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return -1
+	}
 
-		sp := spinner.New("Scanning history for errors...")
-		sp.Start()
-		firstToken := true
+	warnInjection("The command output and history", req.Prompt, false)
+	req.Prompt = guard.Fence(kind, req.Prompt)
 
-		borderColor := lipgloss.Color(ui.ColorOrange)
-		header := ui.HeaderStyle(ui.ColorOrange).Render("🔧 FIX SUGGESTION")
+	sp := spinner.New(spinnerText)
+	sp.Start()
+	firstToken := true
 
-		response, err := pipe.RunStream(prompt, "fix", func(token string) {
-			if firstToken {
-				sp.Stop()
-				firstToken = false
-				fmt.Println(header)
-				fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╔" + strings.Repeat("═", 76) + "╗"))
-				fmt.Print(lipgloss.NewStyle().Foreground(borderColor).Render("║") + "  ")
-			}
-			formatted := strings.ReplaceAll(token, "\n", "\n"+lipgloss.NewStyle().Foreground(borderColor).Render("║")+"  ")
-			fmt.Print(formatted)
-		})
+	borderColor := lipgloss.Color(ui.ColorOrange)
+	header := ui.HeaderStyle(ui.ColorOrange).Render(title)
 
+	response, err := pipe.RunStream(ctx, req, func(token string) {
 		if firstToken {
 			sp.Stop()
+			firstToken = false
+			fmt.Println(header + metaBadges(req.Meta))
+			fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╔" + strings.Repeat("═", 76) + "╗"))
+			fmt.Print(lipgloss.NewStyle().Foreground(borderColor).Render("║") + "  ")
 		}
+		formatted := strings.ReplaceAll(token, "\n", "\n"+lipgloss.NewStyle().Foreground(borderColor).Render("║")+"  ")
+		fmt.Print(formatted)
+	})
 
-		elapsed := time.Since(start)
+	if firstToken {
+		sp.Stop()
+	}
 
-		if err != nil {
-			if !firstToken {
-				fmt.Println()
-			}
-			logger.Log.WithError(err).Error("'fix' command failed")
-			metrics.Record("fix", elapsed, err.Error())
-			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
-			return
+	elapsed := time.Since(start)
+
+	if err != nil {
+		if !firstToken {
+			fmt.Println()
+		}
+		if isCancelled(ctx, err) {
+			logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Warn("'fix' command cancelled")
+			metrics.RecordCancelled("fix", elapsed)
+			fmt.Println(ui.ErrorStyle().Render("⚠️  Cancelled."))
+			return -1
+		}
+		logger.Log.WithError(err).Error("'fix' command failed")
+		metrics.Record("fix", elapsed, err.Error())
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return -1
+	}
+
+	fmt.Println()
+	fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╚" + strings.Repeat("═", 76) + "╝"))
+
+	logger.Log.WithFields(logrus.Fields{
+		"duration_ms": elapsed.Milliseconds(),
+		"cached":      req.Meta.Cached,
+		"redacted":    req.Meta.Redacted,
+	}).Info("'fix' command completed")
+	metrics.Record("fix", elapsed, "")
+	warnDownloadExec(response)
+
+	command := suggest.Command(response)
+	if CopyFlag {
+		copied := response
+		if command != "" {
+			copied = command
 		}
+		if err := clipboard.WriteAll(copied); err != nil {
+			logger.Log.WithError(err).Warn("Failed to copy to clipboard")
+			fmt.Println(ui.ErrorStyle().Render("\n❌ Could not copy: " + err.Error()))
+		} else {
+			fmt.Println("\n✅ Copied to clipboard!")
+		}
+		return -1
+	}
+
+	if command != "" {
+		return offerCommand(ctx, command, "")
+	}
 
+	// No command to run: offer to copy the prose answer instead.
+	fmt.Print("\n📋 Copy suggestion to clipboard? [y/N]: ")
+	input, err := readLine(ctx)
+	if err != nil {
 		fmt.Println()
-		fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╚" + strings.Repeat("═", 76) + "╝"))
+		return -1
+	}
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input == "y" || input == "yes" {
+		if err := clipboard.WriteAll(response); err != nil {
+			logger.Log.WithError(err).Warn("Failed to copy to clipboard")
+			fmt.Println(ui.ErrorStyle().Render("❌ Could not copy: " + err.Error()))
+		} else {
+			logger.Log.Info("Response copied to clipboard")
+			fmt.Println("✅ Copied to clipboard!")
+		}
+	}
+	return -1
+}
 
-		logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Info("'fix' command completed")
-		metrics.Record("fix", elapsed, "")
+// describeFailure renders a journal entry and the surrounding history as the
+// user message for the fix prompt.
+func describeFailure(e *journal.Entry, recent []history.Entry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Failed command: %s\n", e.Command)
+	fmt.Fprintf(&b, "Exit code: %d\n", e.ExitCode)
+	if e.Cwd != "" {
+		fmt.Fprintf(&b, "Working directory: %s\n", e.Cwd)
+	}
+	if !e.Start.IsZero() {
+		fmt.Fprintf(&b, "Ran %s, took %s\n", humanAge(e.Start), humanDuration(e.Duration))
+	}
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		fmt.Fprintf(&b, "Error output (tail):\n%s\n", stderr)
+	}
+	if len(recent) > 0 {
+		fmt.Fprintf(&b, "Recent commands for context, oldest first:\n%s", describeHistory(recent))
+	}
+	return b.String()
+}
 
-		if CopyFlag {
-			if err := clipboard.WriteAll(response); err != nil {
-				logger.Log.WithError(err).Warn("Failed to copy to clipboard")
-				fmt.Println(ui.ErrorStyle().Render("\n❌ Could not copy: " + err.Error()))
-			} else {
-				fmt.Println("\n✅ Copied to clipboard!")
-			}
-			return
+// describeHistory renders history entries one per line, annotated with when
+// they ran and how long they took when the history format records it, e.g.
+// "- make build (ran 3s ago, took 12s)".
+func describeHistory(entries []history.Entry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString("- " + strings.ReplaceAll(e.Command, "\n", "\n  "))
+		var notes []string
+		if !e.Timestamp.IsZero() {
+			notes = append(notes, "ran "+humanAge(e.Timestamp))
+		}
+		if e.Duration > 0 {
+			notes = append(notes, "took "+humanDuration(e.Duration))
 		}
+		if len(notes) > 0 {
+			b.WriteString(" (" + strings.Join(notes, ", ") + ")")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
 
-		// Offer clipboard copy if not already copied via flag
-		fmt.Print("\n📋 Copy suggestion to clipboard? [y/N]: ")
-		reader := bufio.NewReader(os.Stdin)
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(strings.ToLower(input))
-		if input == "y" || input == "yes" {
-			if err := clipboard.WriteAll(response); err != nil {
-				logger.Log.WithError(err).Warn("Failed to copy to clipboard")
-				fmt.Println(ui.ErrorStyle().Render("❌ Could not copy: " + err.Error()))
-			} else {
-				logger.Log.Info("Response copied to clipboard")
-				fmt.Println("✅ Copied to clipboard!")
-			}
+// describeRun renders the result of a command run by `fix -- <command>` as
+// the user message for the fix prompt.
+func describeRun(r *runner.Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Failed command: %s\n", r.Command)
+	if r.TimedOut {
+		fmt.Fprintf(&b, "The command was killed after exceeding its %s timeout.\n", humanDuration(r.Duration))
+	} else {
+		fmt.Fprintf(&b, "Exit code: %d\n", r.ExitCode)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		fmt.Fprintf(&b, "Working directory: %s\n", cwd)
+	}
+	fmt.Fprintf(&b, "Took %s\n", humanDuration(r.Duration))
+	if out := strings.TrimSpace(r.Output); out != "" {
+		label := "Output"
+		if r.Truncated {
+			label = "Output (tail, earlier lines omitted)"
 		}
-	},
+		fmt.Fprintf(&b, "%s:\n%s\n", label, out)
+	}
+	return b.String()
+}
+
+// humanDuration renders a command duration compactly, e.g. "850ms", "12s",
+// "3m4s".
+func humanDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return d.Round(time.Second).String()
 }
 
 func init() {
+	fixCmd.Flags().DurationVar(&fixTimeout, "timeout", 0, "Kill the command run after '--' after this long, e.g. 5m (exit code 124)")
+	fixCmd.Flags().IntVar(&fixMaxOutput, "max-output", runner.DefaultMaxOutput>>10, "KiB of trailing output from the command run after '--' sent to the model")
 	rootCmd.AddCommand(fixCmd)
 }
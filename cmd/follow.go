@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/shell-sage/internal/guard"
+	"github.com/shell-sage/internal/logger"
+	"github.com/shell-sage/internal/logs"
+	"github.com/shell-sage/internal/metrics"
+	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/spinner"
+	"github.com/shell-sage/internal/ui"
+	"github.com/sirupsen/logrus"
+)
+
+// followPoll is how often a followed file is checked for new lines.
+const followPoll = 500 * time.Millisecond
+
+// followLog watches a growing log (a file, or stdin for "-") and diagnoses
+// each error burst as it happens until ctx is cancelled or the input ends.
+func followLog(ctx context.Context, arg string) {
+	name := arg
+	if arg == logs.Stdin {
+		name = "stdin"
+	}
+	pipe, err := buildPipeline()
+	if err != nil {
+		logger.Log.WithError(err).Error("'analyze --follow' failed to build pipeline")
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return
+	}
+
+	det := logs.NewDetector(analyzeBurst, analyzeWindow)
+	lines := make(chan string, 1024)
+	readErr := make(chan error, 1)
+	go func() {
+		emit := func(line string) {
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+			}
+		}
+		if arg == logs.Stdin {
+			readErr <- logs.Stream(ctx, os.Stdin, emit)
+		} else {
+			readErr <- logs.Tail(ctx, arg, followPoll, emit)
+		}
+		close(lines)
+	}()
+
+	// Bursts are detected in their own goroutine so lines keep being read
+	// while a diagnosis is streaming. Incidents queue up behind it.
+	incidents := make(chan *logs.Incident, 8)
+	go func() {
+		defer close(incidents)
+		send := func(inc *logs.Incident) {
+			if inc == nil {
+				return
+			}
+			select {
+			case incidents <- inc:
+			default:
+				logger.Log.WithField("errors", inc.Errors).Warn("Incident dropped, diagnoses are falling behind")
+			}
+		}
+		tick := time.NewTicker(time.Second)
+		defer tick.Stop()
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					send(det.Flush())
+					return
+				}
+				send(det.Add(time.Now(), line))
+			case now := <-tick.C:
+				send(det.Tick(now))
+			}
+		}
+	}()
+
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+	fmt.Println(dim.Render(fmt.Sprintf("👀 Watching %s for error bursts (%d errors within %s). Ctrl-C to stop.", name, analyzeBurst, analyzeWindow)))
+	logger.Log.WithFields(logrus.Fields{
+		"input":     name,
+		"threshold": analyzeBurst,
+		"window":    analyzeWindow.String(),
+	}).Info("Starting 'analyze --follow'")
+
+	for inc := range incidents {
+		diagnoseIncident(ctx, pipe, name, inc)
+	}
+	if err := <-readErr; err != nil {
+		logger.Log.WithError(err).Error("'analyze --follow' stopped reading")
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return
+	}
+	if ctx.Err() != nil {
+		fmt.Println()
+		fmt.Println(dim.Render("👋 Stopped watching " + name + "."))
+	}
+}
+
+// diagnoseIncident asks for a short diagnosis of an error burst and prints
+// it in an incident box.
+func diagnoseIncident(ctx context.Context, pipe *pipeline.Pipeline, name string, inc *logs.Incident) {
+	start := time.Now()
+	opts := generationOptions("analyze")
+	// A burst is short, but cap it to one request in case of huge lines.
+	text := logs.Split(strings.Join(inc.Lines, "\n"), logs.ChunkSize(opts.NumCtx))[0].Text
+
+	req := pipeline.Request{
+		System: guardedSystemPrompt(
+			"You are an on-call SRE. The user sends a burst of error lines that just appeared in a live log, with the lines logged right before it. Give a short diagnosis in max 2 bullet points: the likely cause and the first thing to check. No intro.",
+		),
+		Prompt:  guard.Fence("log", text),
+		Command: "analyze",
+		Options: opts,
+		Meta:    &pipeline.Meta{},
+	}
+
+	title := fmt.Sprintf("🚨 INCIDENT %s › %s · %d errors in %s",
+		inc.Start.Format("15:04:05"), name, inc.Errors, humanDuration(inc.End.Sub(inc.Start)))
+	borderColor := lipgloss.Color(ui.ColorOrange)
+	border := lipgloss.NewStyle().Foreground(borderColor)
+
+	fmt.Println()
+	warnInjection(name, text, false)
+	sp := spinner.New("Diagnosing incident...")
+	sp.Start()
+	firstToken := true
+	response, err := pipe.RunStream(ctx, req, func(token string) {
+		if firstToken {
+			sp.Stop()
+			firstToken = false
+			fmt.Println(ui.HeaderStyle(ui.ColorOrange).Render(title) + metaBadges(req.Meta))
+			fmt.Println(border.Render("╔" + strings.Repeat("═", 76) + "╗"))
+			fmt.Print(border.Render("║") + "  ")
+		}
+		fmt.Print(strings.ReplaceAll(token, "\n", "\n"+border.Render("║")+"  "))
+	})
+	if firstToken {
+		sp.Stop()
+	}
+
+	elapsed := time.Since(start)
+	if err != nil {
+		if !firstToken {
+			fmt.Println()
+		}
+		if isCancelled(ctx, err) {
+			metrics.RecordCancelled("analyze", elapsed)
+			return
+		}
+		logger.Log.WithError(err).Error("Incident diagnosis failed")
+		metrics.Record("analyze", elapsed, err.Error())
+		fmt.Println(ui.ErrorStyle().Render("❌ " + title + ": " + err.Error()))
+		return
+	}
+	fmt.Println()
+	fmt.Println(border.Render("╚" + strings.Repeat("═", 76) + "╝"))
+
+	logger.Log.WithFields(logrus.Fields{
+		"errors":      inc.Errors,
+		"lines":       len(inc.Lines),
+		"duration_ms": elapsed.Milliseconds(),
+	}).Info("Incident diagnosed")
+	metrics.Record("analyze", elapsed, "")
+	warnDownloadExec(response)
+}
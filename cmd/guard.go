@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shell-sage/internal/guard"
+	"github.com/shell-sage/internal/logger"
+	"github.com/shell-sage/internal/safety"
+	"github.com/shell-sage/internal/ui"
+	"github.com/sirupsen/logrus"
+)
+
+// maxInjectionWarnings bounds how many suspicious lines are shown per input.
+const maxInjectionWarnings = 3
+
+// guardedSystemPrompt is systemPrompt for requests whose user message is
+// untrusted text fenced with guard.Fence.
+func guardedSystemPrompt(instructions string) string {
+	return systemPrompt(instructions + " " + guard.Instructions)
+}
+
+// warnInjection tells the user when text from where (a log name, "the
+// command output") contains instruction-like passages. Line numbers are
+// shown when withLines is set, i.e. when they are the input's own.
+func warnInjection(where, text string, withLines bool) {
+	findings := guard.Scan(text)
+	if len(findings) == 0 {
+		return
+	}
+	logger.Log.WithFields(logrus.Fields{
+		"input":    where,
+		"findings": len(findings),
+	}).Warn("Instruction-like text found in input")
+
+	fmt.Println(ui.ErrorStyle().Render(fmt.Sprintf("⚠️  %s contains text that looks like instructions to the AI. It is sent as data and the model is told not to follow it:", where)))
+	for i, f := range findings {
+		if i == maxInjectionWarnings {
+			fmt.Printf("   … and %d more\n", len(findings)-i)
+			break
+		}
+		if withLines {
+			fmt.Printf("   line %d: %s\n", f.Line, truncate(f.Text, 90))
+		} else {
+			fmt.Printf("   %s\n", truncate(f.Text, 90))
+		}
+	}
+}
+
+// warnDownloadExec warns loudly when an answer suggests piping a download
+// into a shell or interpreter, the usual payload of a prompt injection.
+func warnDownloadExec(response string) {
+	var hits []string
+	for _, line := range strings.Split(response, "\n") {
+		if safety.DownloadExec(line) {
+			hits = append(hits, strings.TrimSpace(line))
+		}
+	}
+	if len(hits) == 0 {
+		return
+	}
+	logger.Log.WithField("lines", len(hits)).Warn("Answer suggests running a downloaded script")
+
+	fmt.Println()
+	fmt.Println(ui.ErrorStyle().Render("🚨 WARNING: the answer suggests running a script downloaded from the network:"))
+	for _, h := range hits {
+		fmt.Println(ui.ErrorStyle().Render("   " + truncate(h, 90)))
+	}
+	fmt.Println(ui.ErrorStyle().Render("   Logs or command output may have manipulated the model. Do not run it unless you trust the source."))
+}
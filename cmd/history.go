@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/shell-sage/internal/history"
+	"github.com/shell-sage/internal/journal"
+	"github.com/shell-sage/internal/logger"
+	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/suggest"
+	"github.com/shell-sage/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyLimit   int
+	historyGrep    string
+	historyRegex   string
+	historySince   string
+	historyAll     bool
+	historyExplain bool
+	historyFix     bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [index]",
+	Short: "Browse shell history and explain or fix a past command",
+	Long: `List recent shell history with when each command ran and how long it
+took. With the shell hook installed (see 'ssage init'), the exit code of each
+command is shown too.
+
+Pick an entry by its index to explain it or suggest a fix for it:
+
+  ssage history --grep docker --since 2h
+  ssage history 4821 --explain
+  ssage history 4821 --fix
+
+--since accepts a duration (30m, 2h, 3d) or a date (2024-05-01, "2024-05-01 14:00").
+ssage's own invocations, repeated commands and the history.ignore list are
+left out unless --all is given.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if historyExplain && historyFix {
+			fmt.Println(ui.ErrorStyle().Render("❌ --explain and --fix are mutually exclusive."))
+			os.Exit(2)
+		}
+		if len(args) == 0 {
+			if historyExplain || historyFix {
+				fmt.Println(ui.ErrorStyle().Render("❌ Pick an entry: ssage history <index> --explain"))
+				os.Exit(2)
+			}
+			if err := listHistory(); err != nil {
+				fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+				os.Exit(1)
+			}
+			return
+		}
+
+		index, err := strconv.Atoi(args[0])
+		if err != nil || index < 1 {
+			fmt.Println(ui.ErrorStyle().Render(fmt.Sprintf("❌ Invalid index %q: expected a number from the list printed by 'ssage history'.", args[0])))
+			os.Exit(2)
+		}
+		if code := pickHistory(cmd.Context(), index); code > 0 {
+			os.Exit(code)
+		}
+	},
+}
+
+// listHistory prints the most recent history entries that match the
+// --grep, --regex and --since flags.
+func listHistory() error {
+	var f *history.Filter
+	if !historyAll {
+		var err error
+		if f, err = history.ConfiguredFilter(); err != nil {
+			return err
+		}
+	}
+	if historyGrep != "" {
+		needle := strings.ToLower(historyGrep)
+		f = f.Where(func(e history.Entry) bool {
+			return strings.Contains(strings.ToLower(e.Command), needle)
+		})
+	}
+	if historyRegex != "" {
+		re, err := regexp.Compile(historyRegex)
+		if err != nil {
+			return fmt.Errorf("invalid --regex: %w", err)
+		}
+		f = f.Where(func(e history.Entry) bool { return re.MatchString(e.Command) })
+	}
+	if historySince != "" {
+		since, err := parseSince(historySince, time.Now())
+		if err != nil {
+			return err
+		}
+		f = f.Where(func(e history.Entry) bool { return !e.Timestamp.IsZero() && !e.Timestamp.Before(since) })
+	}
+
+	entries, err := history.RecentFiltered(historyLimit, f)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		msg := "⚠️  No matching history entries."
+		if historySince != "" {
+			msg += "\n  → --since needs timestamps in your history (zsh: setopt EXTENDED_HISTORY, bash: export HISTTIMEFORMAT)"
+		}
+		fmt.Println(ui.ErrorStyle().Render(msg))
+		return nil
+	}
+
+	showExit := journal.Installed()
+	var runs []*journal.Entry
+	if showExit {
+		recorded, err := journal.All()
+		if err != nil {
+			logger.Log.WithError(err).Warn("Failed to read command journal")
+		}
+		runs = matchJournal(entries, recorded)
+	}
+
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+	width := len(strconv.Itoa(entries[len(entries)-1].Line))
+	header := fmt.Sprintf("%*s  %-8s  %6s", width, "#", "WHEN", "TOOK")
+	if showExit {
+		header += "  EXIT"
+	}
+	fmt.Println(dim.Render(header + "  COMMAND"))
+	for i, e := range entries {
+		when, took := "-", "-"
+		if !e.Timestamp.IsZero() {
+			when = humanAge(e.Timestamp)
+		}
+		if e.Duration > 0 {
+			took = humanDuration(e.Duration)
+		}
+		row := fmt.Sprintf("%*d  %-8s  %6s", width, e.Line, when, took)
+		if showExit {
+			row += "  " + exitColumn(runs[i])
+		}
+		fmt.Println(row + "  " + truncate(oneLine(e.Command), 80))
+	}
+	fmt.Println()
+	fmt.Println(dim.Render("Explain or fix an entry: ssage history <#> --explain | --fix"))
+	return nil
+}
+
+// exitColumn renders a journal exit code for the history list, padded to
+// the width of the EXIT header.
+func exitColumn(run *journal.Entry) string {
+	if run == nil {
+		return "   -"
+	}
+	code := fmt.Sprintf("%4d", run.ExitCode)
+	if run.Failed() {
+		return ui.ErrorStyle().Render(code)
+	}
+	return code
+}
+
+// pickHistory explains, fixes or describes the history entry at index. It
+// returns the exit code of a suggested fix the user ran, or -1.
+func pickHistory(ctx context.Context, index int) int {
+	entries, err := history.Before(index, 10)
+	if err != nil {
+		fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
+		return 1
+	}
+	target := entries[len(entries)-1]
+	before := entries[:len(entries)-1]
+	if f, err := history.ConfiguredFilter(); err == nil {
+		before = f.Apply(before)
+	}
+
+	var run *journal.Entry
+	if recorded, err := journal.All(); err == nil {
+		run = matchJournal([]history.Entry{target}, recorded)[0]
+	}
+
+	switch {
+	case historyExplain:
+		explainCommand(ctx, target.Command)
+		return -1
+	case historyFix:
+		return fixHistoryEntry(ctx, target, run, before)
+	}
+
+	fmt.Println(ui.HeaderStyle(ui.ColorCyan).Render(fmt.Sprintf("📜 HISTORY › #%d", target.Line)))
+	fmt.Println(target.Command)
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("#888888"))
+	var notes []string
+	if !target.Timestamp.IsZero() {
+		notes = append(notes, "ran "+humanAge(target.Timestamp))
+	}
+	if target.Duration > 0 {
+		notes = append(notes, "took "+humanDuration(target.Duration))
+	}
+	if run != nil {
+		notes = append(notes, fmt.Sprintf("exit code %d", run.ExitCode))
+		if run.Cwd != "" {
+			notes = append(notes, "in "+run.Cwd)
+		}
+	}
+	if len(notes) > 0 {
+		fmt.Println(dim.Render(strings.Join(notes, ", ")))
+	}
+	fmt.Println()
+	fmt.Println(dim.Render(fmt.Sprintf("ssage history %d --explain | --fix", target.Line)))
+	return -1
+}
+
+// fixHistoryEntry asks for a fix for a past command. When the journal
+// recorded it failing, the exit code and error output are included as for
+// `ssage fix`.
+func fixHistoryEntry(ctx context.Context, target history.Entry, run *journal.Entry, before []history.Entry) int {
+	start := time.Now()
+	logger.Log.WithField("index", target.Line).Info("Starting 'fix' command for a history entry")
+
+	req := pipeline.Request{
+		Command: "fix",
+		Options: generationOptions("fix"),
+		Meta:    &pipeline.Meta{},
+	}
+	kind := "command output"
+	if run != nil && run.Failed() {
+		req.System = guardedSystemPrompt(
+			"You are a shell expert. The user's command failed. Using its exit code and error output, explain the likely cause and the fix in max 3 short bullet points. " + suggest.Format,
+		)
+		req.Prompt = describeFailure(run, before)
+	} else {
+		req.System = guardedSystemPrompt(
+			"You are a shell expert. The user picked a command from their history that failed or did not do what they wanted. Identify the likely problem and explain the fix in max 3 short bullet points. " + suggest.Format,
+		)
+		var b strings.Builder
+		fmt.Fprintf(&b, "Command: %s\n", target.Command)
+		if run != nil {
+			fmt.Fprintf(&b, "Exit code: %d\n", run.ExitCode)
+		}
+		if len(before) > 0 {
+			fmt.Fprintf(&b, "Commands run before it, oldest first:\n%s", describeHistory(before))
+		}
+		req.Prompt = b.String()
+		kind = "shell history"
+	}
+	title := "🔧 FIX SUGGESTION › " + truncate(oneLine(target.Command), 60)
+	return streamFix(ctx, start, req, kind, title, "Analyzing command...")
+}
+
+// journalSkew is how far apart a history timestamp and the journal's start
+// time for the same run may be. Shells stamp history entries when the
+// command is read, the hook when it starts executing.
+const journalSkew = 5 * time.Second
+
+// matchJournal pairs history entries with the journal records of the same
+// runs, returning a slice parallel to entries with nil where no record
+// matches. Both lists are walked from newest to oldest; a record matches
+// when its command is the same and, if both carry times, it started within
+// journalSkew of the history timestamp.
+func matchJournal(entries []history.Entry, recorded []journal.Entry) []*journal.Entry {
+	runs := make([]*journal.Entry, len(entries))
+	next := len(recorded) - 1
+	for i := len(entries) - 1; i >= 0 && next >= 0; i-- {
+		e := entries[i]
+		command := strings.TrimSpace(e.Command)
+		for k := next; k >= 0; k-- {
+			r := &recorded[k]
+			if !e.Timestamp.IsZero() && !r.Start.IsZero() {
+				if r.Start.Before(e.Timestamp.Add(-journalSkew)) {
+					break // older than the entry: it was not recorded
+				}
+				if r.Start.After(e.Timestamp.Add(journalSkew)) {
+					continue
+				}
+			}
+			if strings.TrimSpace(r.Command) == command {
+				runs[i] = r
+				next = k - 1
+				break
+			}
+		}
+	}
+	return runs
+}
+
+// parseSince parses a --since value: a duration before now, with "d" for
+// days (e.g. "2h", "3d"), or a local date or date and time.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration (30m, 2h, 3d) or a date (2024-05-01, \"2024-05-01 14:00\")", s)
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of entries to list")
+	historyCmd.Flags().StringVarP(&historyGrep, "grep", "g", "", "Only list commands containing this text (case-insensitive)")
+	historyCmd.Flags().StringVarP(&historyRegex, "regex", "e", "", "Only list commands matching this regular expression")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only list commands run within this window, e.g. 2h, 3d or 2024-05-01")
+	historyCmd.Flags().BoolVarP(&historyAll, "all", "a", false, "Include commands on the history.ignore list")
+	historyCmd.Flags().BoolVar(&historyExplain, "explain", false, "Explain the picked entry")
+	historyCmd.Flags().BoolVar(&historyFix, "fix", false, "Suggest a fix for the picked entry")
+	rootCmd.AddCommand(historyCmd)
+}
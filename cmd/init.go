@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/shell-sage/internal/journal"
+	"github.com/shell-sage/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var initCaptureStderr bool
+
+var initCmd = &cobra.Command{
+	Use:   "init [bash|zsh|fish]",
+	Short: "Print the shell integration hook that records command outcomes",
+	Long: `Print a shell snippet that records each command's exit code, duration
+and working directory to ~/.ssage_journal.jsonl. With the hook installed,
+'ssage fix' targets the most recent failed command and its error output
+instead of guessing from plain history.
+
+  bash:  echo 'eval "$(ssage init bash)"' >> ~/.bashrc
+  zsh:   echo 'eval "$(ssage init zsh)"' >> ~/.zshrc
+  fish:  echo 'ssage init fish | source' >> ~/.config/fish/config.fish
+
+--capture-stderr additionally tees each command's stderr (bash/zsh only).
+Programs then see a pipe instead of a terminal on stderr, which may disable
+colors or progress bars.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: journal.Shells,
+	Run: func(cmd *cobra.Command, args []string) {
+		exe, err := os.Executable()
+		if err != nil {
+			exe = "ssage"
+		}
+		snippet, err := journal.Hook(args[0], exe, initCaptureStderr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, ui.ErrorStyle().Render("❌ "+err.Error()))
+			os.Exit(1)
+		}
+		fmt.Print(snippet)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	initCmd.Flags().BoolVar(&initCaptureStderr, "capture-stderr", false, "Also record the tail of each command's stderr (bash/zsh)")
+}
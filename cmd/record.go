@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shell-sage/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recordExit       int
+	recordStart      string
+	recordEnd        string
+	recordDurationMs int64
+	recordCwd        string
+	recordShell      string
+	recordStderrFile string
+)
+
+// recordCmd is invoked by the `ssage init` hooks after every command. It is
+// hidden from help output and must stay fast and silent.
+var recordCmd = &cobra.Command{
+	Use:    "_record -- <command>",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		command := strings.TrimSpace(strings.Join(args, " "))
+		if command == "" {
+			return
+		}
+
+		e := journal.Entry{
+			Command:  command,
+			ExitCode: recordExit,
+			Cwd:      recordCwd,
+			Shell:    recordShell,
+		}
+
+		end := time.Now()
+		if t, ok := parseEpoch(recordEnd); ok {
+			end = t
+		}
+		if start, ok := parseEpoch(recordStart); ok {
+			e.Start = start
+			e.Duration = end.Sub(start)
+		} else {
+			e.Duration = time.Duration(recordDurationMs) * time.Millisecond
+			e.Start = end.Add(-e.Duration)
+		}
+		if e.Duration < 0 {
+			e.Duration = 0
+		}
+
+		if recordStderrFile != "" {
+			e.Stderr = readTail(recordStderrFile, journal.MaxStderrBytes)
+		}
+
+		_ = journal.Append(e)
+	},
+}
+
+// parseEpoch parses a Unix timestamp with optional fraction, as produced by
+// $EPOCHREALTIME (which uses the locale's decimal separator).
+func parseEpoch(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	f, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)), true
+}
+
+// readTail returns up to max bytes from the end of the file at path.
+func readTail(path string, max int64) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > max {
+		_, _ = f.Seek(-max, io.SeekEnd)
+	}
+	data, _ := io.ReadAll(io.LimitReader(f, max))
+	return string(data)
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+	f := recordCmd.Flags()
+	f.IntVar(&recordExit, "exit", 0, "exit status of the command")
+	f.StringVar(&recordStart, "start", "", "start time as Unix seconds")
+	f.StringVar(&recordEnd, "end", "", "end time as Unix seconds")
+	f.Int64Var(&recordDurationMs, "duration-ms", 0, "duration in milliseconds (when --start is not given)")
+	f.StringVar(&recordCwd, "cwd", "", "working directory")
+	f.StringVar(&recordShell, "shell", "", "shell name")
+	f.StringVar(&recordStderrFile, "stderr-file", "", "file holding the command's captured stderr")
+}
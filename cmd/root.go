@@ -1,15 +1,24 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/shell-sage/internal/config"
 	"github.com/shell-sage/internal/pipeline"
 	"github.com/shell-sage/internal/pipeline/middleware/cache"
 	"github.com/shell-sage/internal/pipeline/middleware/enhancer"
+	"github.com/shell-sage/internal/pipeline/middleware/redact"
 	"github.com/shell-sage/internal/pipeline/middleware/retry"
 	"github.com/shell-sage/internal/provider"
+	"github.com/shell-sage/internal/ui"
 
 	"github.com/spf13/cobra"
 )
@@ -23,10 +32,20 @@ var LangFlag string
 // CopyFlag determines if the output should be copied to the clipboard.
 var CopyFlag bool
 
-// ProviderFlag holds the value of the --provider flag (e.g. "ollama").
+// ProviderFlag holds the value of the --provider flag (e.g. "ollama", "openai").
 // Priority at runtime: flag > SSAGE_PROVIDER env > config file > "ollama".
 var ProviderFlag string
 
+// Generation option flags. They override the config file only when set
+// explicitly on the command line (see generationOptions).
+var (
+	TemperatureFlag float64
+	NumCtxFlag      int
+	SeedFlag        int
+	StopFlag        []string
+	KeepAliveFlag   string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "ssage",
 	Short: "Shell Sage - Your AI Terminal Assistant",
@@ -52,8 +71,34 @@ Providers are pluggable: use --provider to select a backend (default: ollama).`,
 	},
 }
 
+// Execute runs the root command with a context that is cancelled on Ctrl-C
+// or SIGTERM, so in-flight requests, retry backoffs and spinners shut down
+// cleanly instead of the process dying mid-line. A second signal falls back
+// to the default behavior and terminates immediately.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	caught := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		s := <-sigs
+		// A second signal gets the default behaviour and kills the process.
+		signal.Stop(sigs)
+		caught <- s
+		cancel()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	if ctx.Err() != nil {
+		// Conventional exit status for a fatal signal: 128 + its number
+		// (130 for SIGINT, 143 for SIGTERM).
+		code := 130
+		if s, ok := (<-caught).(syscall.Signal); ok {
+			code = 128 + int(s)
+		}
+		os.Exit(code)
+	}
+	if err != nil {
 		os.Exit(1)
 	}
 }
@@ -63,25 +108,170 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&ModelFlag, "model", "m", "", "LLM model to use (e.g. llama3, mistral)")
 	rootCmd.PersistentFlags().StringVarP(&LangFlag, "lang", "l", "", "Response language (e.g. 'es' for Spanish, 'fr' for French)")
 	rootCmd.PersistentFlags().BoolVarP(&CopyFlag, "copy", "c", false, "Copy the suggested command or explanation to clipboard")
-	rootCmd.PersistentFlags().StringVarP(&ProviderFlag, "provider", "p", "", "AI provider to use (e.g. ollama)")
+	rootCmd.PersistentFlags().StringVarP(&ProviderFlag, "provider", "p", "", "AI provider to use (e.g. ollama, openai)")
+	rootCmd.PersistentFlags().Float64Var(&TemperatureFlag, "temperature", 0, "Sampling temperature (0 for reproducible answers)")
+	rootCmd.PersistentFlags().IntVar(&NumCtxFlag, "num-ctx", 0, "Model context window in tokens (Ollama)")
+	rootCmd.PersistentFlags().IntVar(&SeedFlag, "seed", 0, "Random seed for reproducible sampling")
+	rootCmd.PersistentFlags().StringSliceVar(&StopFlag, "stop", nil, "Stop sequences (comma-separated or repeated)")
+	rootCmd.PersistentFlags().StringVar(&KeepAliveFlag, "keep-alive", "", "How long the model stays loaded after the request, e.g. 10m (Ollama)")
+}
+
+// generationOptions returns the effective generation options for command.
+// Priority per field: explicit flag > config command_options > config
+// options > backend default.
+func generationOptions(command string) provider.Options {
+	var o config.GenerationOptions
+	if cfg, err := config.Load(); err == nil {
+		o = cfg.For(command)
+	}
+
+	flags := rootCmd.PersistentFlags()
+	if flags.Changed("temperature") {
+		t := TemperatureFlag
+		o.Temperature = &t
+	}
+	if flags.Changed("num-ctx") {
+		o.NumCtx = NumCtxFlag
+	}
+	if flags.Changed("seed") {
+		s := SeedFlag
+		o.Seed = &s
+	}
+	if flags.Changed("stop") {
+		o.Stop = StopFlag
+	}
+	if flags.Changed("keep-alive") {
+		o.KeepAlive = KeepAliveFlag
+	}
+
+	return provider.Options{
+		Temperature: o.Temperature,
+		NumCtx:      o.NumCtx,
+		Seed:        o.Seed,
+		Stop:        o.Stop,
+		KeepAlive:   o.KeepAlive,
+	}
 }
 
 // buildPipeline creates a ready-to-use Pipeline wired with the standard
-// middleware stack: enhancer → cache → retry → provider.
+// middleware stack: enhancer → redact → cache → retry → provider.
 //
 // The middleware order ensures that:
-//  1. enhancer runs first to inject OS/Shell context into the prompt.
-//  2. cache uses the enhanced prompt as its key and short-circuits on a hit.
-//  3. retry wraps the actual provider call to handle transient errors.
+//  1. enhancer runs first to inject OS/Shell context into the system message.
+//  2. redact masks secrets before anything is persisted or sent.
+//  3. cache uses the enhanced, redacted request as its key and short-circuits
+//     on a hit.
+//  4. retry wraps the actual provider call to handle transient errors.
 func buildPipeline() (*pipeline.Pipeline, error) {
 	p, err := provider.New(ProviderFlag, ModelFlag)
 	if err != nil {
 		return nil, err
 	}
+	var patterns []string
+	if cfg, err := config.Load(); err == nil {
+		patterns = cfg.Redact.Patterns
+	}
+	redactor, err := redact.New(patterns...)
+	if err != nil {
+		return nil, err
+	}
+	ttl, limits := cachePolicy()
 	return pipeline.New(
 		p,
 		enhancer.New(),
-		cache.New(24*time.Hour, "tip"),
+		redactor,
+		cache.New(ttl, limits, "tip"),
 		retry.New(3),
 	), nil
 }
+
+// defaultCacheTTL is how long cached answers stay valid unless overridden by
+// the cache.ttl config key.
+const defaultCacheTTL = 24 * time.Hour
+
+// cachePolicy returns the cache TTL and size limits from the config file,
+// falling back to the built-in defaults.
+func cachePolicy() (time.Duration, cache.Limits) {
+	ttl := defaultCacheTTL
+	var limits cache.Limits
+	cfg, err := config.Load()
+	if err != nil {
+		return ttl, limits
+	}
+	if d, err := time.ParseDuration(cfg.Cache.TTL); err == nil && d > 0 {
+		ttl = d
+	}
+	limits.MaxEntries = cfg.Cache.MaxEntries
+	limits.MaxBytes = int64(cfg.Cache.MaxSizeMB) << 20
+	return ttl, limits
+}
+
+// isCancelled reports whether err was caused by the user interrupting the
+// command rather than by a genuine failure.
+func isCancelled(ctx context.Context, err error) bool {
+	return errors.Is(err, context.Canceled) || ctx.Err() != nil
+}
+
+// stdinReader is shared by all prompts so that answers piped in on
+// consecutive lines are not swallowed by an earlier prompt's buffer.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readLine reads a single line from stdin. It returns ctx.Err() as soon as
+// ctx is cancelled so interactive prompts do not block shutdown, and the
+// read error, io.EOF included, when stdin fails or is exhausted. Prompts
+// treat either as the user cancelling. A last line without a newline is
+// still returned as an answer; the next call reports io.EOF.
+func readLine(ctx context.Context) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		line, err := stdinReader.ReadString('\n')
+		ch <- result{line, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err == io.EOF && r.line != "" {
+			return r.line, nil
+		}
+		return r.line, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal rather
+// than a pipe or file, i.e. whether there is someone to answer prompts.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// metaBadges returns subtle header suffixes describing how the response was
+// served: " · cached" when replayed from the cache and " · 🔒 N redacted"
+// when secrets were masked. Returns "" when there is nothing to report.
+func metaBadges(meta *pipeline.Meta) string {
+	if meta == nil {
+		return ""
+	}
+	var badges string
+	if meta.Cached {
+		badges += " " + ui.BadgeStyle().Render("· cached")
+	}
+	if meta.Redacted > 0 {
+		badges += " " + ui.BadgeStyle().Render(fmt.Sprintf("· 🔒 %d redacted", meta.Redacted))
+	}
+	return badges
+}
+
+// systemPrompt builds the system message for a command: the role and format
+// instructions followed by a strict response-language directive.
+func systemPrompt(instructions string) string {
+	lang := "English"
+	if LangFlag != "" {
+		lang = LangFlag
+	}
+	return fmt.Sprintf("%s\nYou MUST respond ONLY in %s. Do not use any other language.", instructions, lang)
+}
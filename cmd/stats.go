@@ -65,6 +65,7 @@ var statsCmd = &cobra.Command{
 				"fix":     "🔧",
 				"analyze": "🧠",
 				"tip":     "💡",
+				"do":      "🪄",
 			}[name]
 			if icon == "" {
 				icon = "▸"
@@ -86,6 +87,9 @@ var statsCmd = &cobra.Command{
 			)
 			fmt.Printf("  %s %s\n", labelStyle.Render("Runs:"), valueStyle.Render(fmt.Sprintf("%d", stat.Runs)))
 			fmt.Printf("  %s %s  (%d%% failure rate)\n", labelStyle.Render("Failures:"), failsRendered, failRate)
+			if stat.Cancelled > 0 {
+				fmt.Printf("  %s %s\n", labelStyle.Render("Cancelled:"), valueStyle.Render(fmt.Sprintf("%d", stat.Cancelled)))
+			}
 			fmt.Printf("  %s %s\n", labelStyle.Render("Avg Duration:"), valueStyle.Render(fmt.Sprintf("%dms", stat.AvgTimeMs)))
 			if stat.LastError != "" {
 				fmt.Printf("  %s %s\n", labelStyle.Render("Last Error:"),
@@ -8,8 +8,10 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/shell-sage/internal/logger"
 	"github.com/shell-sage/internal/metrics"
+	"github.com/shell-sage/internal/pipeline"
 	"github.com/shell-sage/internal/spinner"
 	"github.com/shell-sage/internal/ui"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -18,16 +20,18 @@ var tipCmd = &cobra.Command{
 	Short: "Get a quick, useful terminal tip from the AI",
 	Run: func(cmd *cobra.Command, args []string) {
 		start := time.Now()
+		ctx := cmd.Context()
 		logger.Log.Info("Starting 'tip' command")
 
-		lang := "English"
-		if LangFlag != "" {
-			lang = LangFlag
+		req := pipeline.Request{
+			System: systemPrompt(
+				"You are a seasoned terminal power user. Be concise, max 3 sentences. No intro text.",
+			),
+			Prompt:  "Give me ONE practical, specific terminal/shell tip that most developers don't know.",
+			Command: "tip",
+			Options: generationOptions("tip"),
+			Meta:    &pipeline.Meta{},
 		}
-		prompt := fmt.Sprintf(
-			"IMPORTANT: You MUST respond ONLY in %s. Do not use any other language.\nGive me ONE practical, specific terminal/shell tip that most developers don't know. Be concise, max 3 sentences. No intro text.",
-			lang,
-		)
 
 		pipe, err := buildPipeline()
 		if err != nil {
@@ -45,11 +49,11 @@ var tipCmd = &cobra.Command{
 		borderColor := lipgloss.Color("#FFD700")
 		header := ui.HeaderStyle("#FFD700").Render("💡 TERMINAL TIP")
 
-		response, err := pipe.RunStream(prompt, "tip", func(token string) {
+		response, err := pipe.RunStream(ctx, req, func(token string) {
 			if firstToken {
 				sp.Stop()
 				firstToken = false
-				fmt.Println(header)
+				fmt.Println(header + metaBadges(req.Meta))
 				fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╭" + strings.Repeat("─", 76) + "╮"))
 				fmt.Print(lipgloss.NewStyle().Foreground(borderColor).Render("│") + "  ")
 			}
@@ -67,6 +71,12 @@ var tipCmd = &cobra.Command{
 			if !firstToken {
 				fmt.Println()
 			}
+			if isCancelled(ctx, err) {
+				logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Warn("'tip' command cancelled")
+				metrics.RecordCancelled("tip", elapsed)
+				fmt.Println(ui.ErrorStyle().Render("⚠️  Cancelled."))
+				return
+			}
 			logger.Log.WithError(err).Error("'tip' command failed")
 			metrics.Record("tip", elapsed, err.Error())
 			fmt.Println(ui.ErrorStyle().Render("❌ " + err.Error()))
@@ -76,7 +86,11 @@ var tipCmd = &cobra.Command{
 		fmt.Println()
 		fmt.Println(lipgloss.NewStyle().Foreground(borderColor).Render("╰" + strings.Repeat("─", 76) + "╯"))
 
-		logger.Log.WithField("duration_ms", elapsed.Milliseconds()).Info("'tip' command completed")
+		logger.Log.WithFields(logrus.Fields{
+			"duration_ms": elapsed.Milliseconds(),
+			"cached":      req.Meta.Cached,
+			"redacted":    req.Meta.Redacted,
+		}).Info("'tip' command completed")
 		metrics.Record("tip", elapsed, "")
 		_ = response
 	},
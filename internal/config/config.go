@@ -2,14 +2,167 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 type Config struct {
 	Model    string `json:"model"`
 	Lang     string `json:"lang"`
 	Provider string `json:"provider,omitempty"`
+
+	// Options are generation defaults applied to every command.
+	Options GenerationOptions `json:"options"`
+
+	// CommandOptions override Options per command name (e.g. "analyze").
+	CommandOptions map[string]GenerationOptions `json:"command_options,omitempty"`
+
+	// Cache bounds the response cache in ~/.ssage_cache.
+	Cache CacheConfig `json:"cache"`
+
+	// Redact configures secret masking applied before prompts leave the
+	// process.
+	Redact RedactConfig `json:"redact"`
+
+	// Fix configures `ssage fix -- <command>`.
+	Fix FixConfig `json:"fix"`
+
+	// History selects where shell history is read from and what is left
+	// out of it.
+	History HistoryConfig `json:"history"`
+}
+
+// HistoryConfig controls where shell history is read from and which
+// commands are left out of the context sent to the model.
+type HistoryConfig struct {
+	// Source forces a history source by name (e.g. "zsh", "nushell")
+	// instead of detecting it from $SHELL.
+	Source string `json:"source,omitempty"`
+
+	// Ignore lists command names left out of history context. When nil the
+	// built-in list (ls, cd, clear, …) is used; an empty list ignores none.
+	Ignore *[]string `json:"ignore,omitempty"`
+
+	// IgnorePatterns are regular expressions; matching commands are left
+	// out of history context.
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+}
+
+// FixConfig bounds commands run by `ssage fix -- <command>`. Zero values use
+// the built-in defaults.
+type FixConfig struct {
+	Timeout     string `json:"timeout,omitempty"` // e.g. "10m"; empty means no limit
+	MaxOutputKB int    `json:"max_output_kb,omitempty"`
+}
+
+// RedactConfig holds user-supplied secret patterns in addition to the
+// built-in rules. If a pattern has a capture group, only the first group is
+// masked.
+type RedactConfig struct {
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// CacheConfig holds the response cache policy. Zero values use the built-in
+// defaults.
+type CacheConfig struct {
+	TTL        string `json:"ttl,omitempty"` // e.g. "24h"
+	MaxEntries int    `json:"max_entries,omitempty"`
+	MaxSizeMB  int    `json:"max_size_mb,omitempty"`
+}
+
+// GenerationOptions is the persisted form of provider generation options.
+// Unset fields fall back to the next layer (command → global → backend).
+type GenerationOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	KeepAlive   string   `json:"keep_alive,omitempty"`
+}
+
+// OptionKeys lists the keys accepted by GenerationOptions.Set.
+var OptionKeys = []string{"temperature", "num_ctx", "seed", "stop", "keep_alive"}
+
+// ErrUnknownOption is returned by GenerationOptions.Set for unknown keys.
+var ErrUnknownOption = errors.New("unknown option")
+
+// Set parses value and assigns it to the option named key. Stop sequences
+// are given as a comma-separated list. An empty value clears the option.
+func (o *GenerationOptions) Set(key, value string) error {
+	switch key {
+	case "temperature":
+		if value == "" {
+			o.Temperature = nil
+			return nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("temperature must be a number: %w", err)
+		}
+		o.Temperature = &f
+	case "num_ctx":
+		if value == "" {
+			o.NumCtx = 0
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("num_ctx must be a positive integer")
+		}
+		o.NumCtx = n
+	case "seed":
+		if value == "" {
+			o.Seed = nil
+			return nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("seed must be an integer: %w", err)
+		}
+		o.Seed = &n
+	case "stop":
+		o.Stop = nil
+		for _, s := range strings.Split(value, ",") {
+			if s != "" {
+				o.Stop = append(o.Stop, s)
+			}
+		}
+	case "keep_alive":
+		o.KeepAlive = value
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownOption, key)
+	}
+	return nil
+}
+
+// Merge returns o with every field that is set in override replaced.
+func (o GenerationOptions) Merge(override GenerationOptions) GenerationOptions {
+	if override.Temperature != nil {
+		o.Temperature = override.Temperature
+	}
+	if override.NumCtx > 0 {
+		o.NumCtx = override.NumCtx
+	}
+	if override.Seed != nil {
+		o.Seed = override.Seed
+	}
+	if len(override.Stop) > 0 {
+		o.Stop = override.Stop
+	}
+	if override.KeepAlive != "" {
+		o.KeepAlive = override.KeepAlive
+	}
+	return o
+}
+
+// For returns the effective options for a command: the global defaults with
+// the command-specific overrides applied on top.
+func (c *Config) For(command string) GenerationOptions {
+	return c.Options.Merge(c.CommandOptions[command])
 }
 
 func GetConfigPath() (string, error) {
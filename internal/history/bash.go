@@ -0,0 +1,106 @@
+package history
+
+import (
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(bashSource{})
+}
+
+// bashSource reads bash history from $HISTFILE or ~/.bash_history.
+type bashSource struct{}
+
+func (bashSource) Name() string { return ShellBash }
+
+func (bashSource) Active() bool { return shellIs("bash") }
+
+func (s bashSource) Paths(home string) []string {
+	return dedupe([]string{
+		histfile(s.Active()),
+		filepath.Join(home, ".bash_history"),
+		filepath.Join(xdgDir("XDG_STATE_HOME", home, ".local/state"), "bash", "history"),
+	})
+}
+
+func (bashSource) Read(path string) ([]Entry, error) { return readFile(path, ParseBash) }
+
+func (bashSource) OpenTail(path string) (Tail, error) {
+	return openTail(path, ParseBash, bashBoundary)
+}
+
+// bashBoundary finds the first record start in a chunk of bash history: the
+// first "#<epoch>" line when the history is timestamped, since the lines up
+// to the next one may belong to a multi-line command, or else any line.
+func bashBoundary(buf []byte) int {
+	if i := lineBoundary(func(_, line []byte) bool {
+		_, ok := bashTimestamp(string(line))
+		return ok
+	})(buf); i >= 0 {
+		return i
+	}
+	return lineBoundary(anyLine)(buf)
+}
+
+func (bashSource) Hint() string {
+	return "  → Check where bash writes history: echo $HISTFILE\n" +
+		"  → If it is not ~/.bash_history, export it from ~/.bashrc: export HISTFILE\n" +
+		"  → Run a few commands and then try again"
+}
+
+// ParseBash parses bash history. When HISTTIMEFORMAT is set, bash writes a
+// "#<epoch>" comment line before each command; all lines up to the next
+// timestamp belong to that (possibly multi-line) command. Without
+// timestamps every line is a command.
+func ParseBash(r io.Reader) ([]Entry, error) {
+	var (
+		entries []Entry
+		cur     *Entry
+	)
+	flush := func() {
+		if cur != nil && strings.TrimSpace(cur.Command) != "" {
+			entries = append(entries, *cur)
+		}
+		cur = nil
+	}
+
+	sc := newScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if ts, ok := bashTimestamp(line); ok {
+			flush()
+			cur = &Entry{Timestamp: ts, Shell: ShellBash, Line: n + 1}
+			continue
+		}
+		switch {
+		case cur != nil && cur.Command == "":
+			cur.Command = line
+			cur.Line = n
+		case cur != nil:
+			cur.Command += "\n" + line
+		default:
+			if strings.TrimSpace(line) != "" {
+				entries = append(entries, Entry{Command: line, Shell: ShellBash, Line: n})
+			}
+		}
+	}
+	flush()
+	return entries, sc.Err()
+}
+
+// bashTimestamp parses a "#<epoch>" history comment line.
+func bashTimestamp(line string) (time.Time, bool) {
+	digits, ok := strings.CutPrefix(line, "#")
+	if !ok || digits == "" {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || sec < 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
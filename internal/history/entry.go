@@ -0,0 +1,89 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"time"
+)
+
+// Shell names used in Entry.Shell.
+const (
+	ShellBash  = "bash"
+	ShellZsh   = "zsh"
+	ShellFish  = "fish"
+	ShellPwsh  = "pwsh"
+	ShellNu    = "nushell"
+	ShellXonsh = "xonsh"
+)
+
+// Entry is one command read from a shell history file. Timestamp and
+// Duration are zero when the history format does not record them.
+type Entry struct {
+	Command   string
+	Timestamp time.Time
+	Duration  time.Duration
+
+	// Shell is the shell whose history format the entry was parsed from.
+	Shell string
+
+	// Line is the 1-based line of the history file the entry starts on, or
+	// its position for formats that are not line-based (SQLite, JSON).
+	Line int
+
+	// Paths lists the file arguments fish recorded as existing when the
+	// command ran. Other shells leave it empty.
+	Paths []string
+}
+
+// Parser reads every entry from a history file, oldest first.
+type Parser func(r io.Reader) ([]Entry, error)
+
+// lineScanner splits a history file into lines like bufio.Scanner with
+// ScanLines, but without a maximum line length, so a pasted multi-megabyte
+// command does not abort parsing.
+type lineScanner struct {
+	r    *bufio.Reader
+	line []byte
+	err  error
+}
+
+func newScanner(r io.Reader) *lineScanner {
+	return &lineScanner{r: bufio.NewReaderSize(r, 64<<10)}
+}
+
+// Scan advances to the next line, reporting false at EOF or on error.
+func (s *lineScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	line, err := s.r.ReadBytes('\n')
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+			return false
+		}
+		if len(line) == 0 {
+			s.err = io.EOF
+			return false
+		}
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	s.line = bytes.TrimSuffix(line, []byte("\r"))
+	return true
+}
+
+// Bytes returns the current line without its line ending. The slice is
+// only valid until the next call to Scan.
+func (s *lineScanner) Bytes() []byte { return s.line }
+
+// Text returns the current line without its line ending.
+func (s *lineScanner) Text() string { return string(s.line) }
+
+// Err returns the first non-EOF error encountered.
+func (s *lineScanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
@@ -0,0 +1,176 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/shell-sage/internal/config"
+)
+
+// DefaultIgnore lists the commands dropped from history context unless the
+// history.ignore config key replaces it. They say little about what the
+// user was trying to do and would crowd out the commands that do.
+var DefaultIgnore = []string{"ls", "ll", "la", "l", "cd", "pwd", "clear", "cls", "reset", "exit", "history"}
+
+// Filter selects the history entries worth showing the model. It drops
+// ssage's own invocations, commands whose name is on the ignore list and
+// commands matching an ignore pattern, and collapses consecutive duplicates
+// into their most recent run.
+type Filter struct {
+	self     map[string]bool
+	ignore   map[string]bool
+	patterns []*regexp.Regexp
+	where    []func(Entry) bool
+}
+
+// NewFilter returns a Filter ignoring the given command names and regular
+// expressions. Patterns are matched against the whole command.
+func NewFilter(ignore, patterns []string) (*Filter, error) {
+	f := &Filter{
+		self:   selfNames(),
+		ignore: make(map[string]bool, len(ignore)),
+	}
+	for _, name := range ignore {
+		if name = strings.TrimSpace(name); name != "" {
+			f.ignore[name] = true
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid history ignore pattern %q: %w", p, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// ConfiguredFilter returns the Filter described by the history.ignore and
+// history.ignore_patterns config keys, using DefaultIgnore when no ignore
+// list is configured.
+func ConfiguredFilter() (*Filter, error) {
+	ignore := DefaultIgnore
+	var patterns []string
+	if cfg, err := config.Load(); err == nil {
+		if cfg.History.Ignore != nil {
+			ignore = *cfg.History.Ignore
+		}
+		patterns = cfg.History.IgnorePatterns
+	}
+	return NewFilter(ignore, patterns)
+}
+
+// Where returns a copy of f that also drops the entries for which keep
+// reports false, e.g. to search history. On a nil Filter it only collapses
+// duplicates besides applying keep.
+func (f *Filter) Where(keep func(Entry) bool) *Filter {
+	g := &Filter{}
+	if f != nil {
+		*g = *f
+	}
+	g.where = append(g.where[:len(g.where):len(g.where)], keep)
+	return g
+}
+
+// Apply returns the entries f keeps, oldest first. A nil Filter keeps every
+// entry.
+func (f *Filter) Apply(entries []Entry) []Entry {
+	if f == nil {
+		return entries
+	}
+	kept := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		if f.skip(e) {
+			continue
+		}
+		if n := len(kept); n > 0 && sameCommand(kept[n-1].Command, e.Command) {
+			kept[n-1] = e // keep the latest run's timestamp and duration
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// skip reports whether e is filtered out.
+func (f *Filter) skip(e Entry) bool {
+	name := commandName(e.Command)
+	if f.self[name] || f.ignore[name] {
+		return true
+	}
+	for _, re := range f.patterns {
+		if re.MatchString(e.Command) {
+			return true
+		}
+	}
+	for _, keep := range f.where {
+		if !keep(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSelf reports whether command runs ssage itself.
+func IsSelf(command string) bool {
+	return selfNames()[commandName(command)]
+}
+
+// selfNames returns the program names ssage runs under: "ssage" and the
+// name it was installed or built as.
+func selfNames() map[string]bool {
+	names := map[string]bool{"ssage": true}
+	if exe := commandBase(os.Args[0]); exe != "" {
+		names[exe] = true
+	}
+	return names
+}
+
+// sameCommand reports whether a and b differ at most in surrounding space.
+func sameCommand(a, b string) bool {
+	return strings.TrimSpace(a) == strings.TrimSpace(b)
+}
+
+// wrappers are words that run the command following them.
+var wrappers = map[string]bool{
+	"sudo": true, "command": true, "builtin": true, "exec": true,
+	"noglob": true, "nocorrect": true, "time": true, "env": true,
+}
+
+// commandName returns the base name of the program command runs, skipping
+// leading variable assignments and wrappers such as sudo.
+func commandName(command string) string {
+	line, _, _ := strings.Cut(command, "\n")
+	wrapped := false
+	for _, word := range strings.Fields(line) {
+		switch {
+		case wrappers[word]:
+			wrapped = true
+		case wrapped && strings.HasPrefix(word, "-"):
+		case isAssignment(word):
+		default:
+			return commandBase(word)
+		}
+	}
+	return ""
+}
+
+// isAssignment reports whether word is a VAR=value prefix.
+func isAssignment(word string) bool {
+	i := strings.IndexByte(word, '=')
+	return i > 0 && !strings.ContainsAny(word[:i], "/'\"-")
+}
+
+// commandBase strips the directory and a Windows executable suffix from a
+// program path, e.g. "./bin/ssage.exe" → "ssage".
+func commandBase(program string) string {
+	program = strings.Trim(program, `'"`)
+	if program == "" {
+		return ""
+	}
+	base := path.Base(strings.ReplaceAll(program, `\`, "/"))
+	return strings.TrimSuffix(strings.TrimSuffix(base, ".exe"), ".EXE")
+}
@@ -0,0 +1,161 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func commands(entries []Entry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Command
+	}
+	return out
+}
+
+// TestFilter_Apply verifies self-invocations, ignored names and patterns are
+// dropped and consecutive duplicates collapse into their latest run.
+func TestFilter_Apply(t *testing.T) {
+	f, err := NewFilter(DefaultIgnore, []string{`^git (status|log)\b`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []Entry{
+		{Command: "make build"},
+		{Command: "ls -la"},
+		{Command: "make test", Duration: time.Second},
+		{Command: "make test ", Duration: 2 * time.Second},
+		{Command: "git status"},
+		{Command: "make test", Duration: 3 * time.Second},
+		{Command: "cd src"},
+		{Command: "go vet ./..."},
+		{Command: "sudo -E /usr/local/bin/ssage fix"},
+		{Command: "DEBUG=1 ssage.exe analyze app.log"},
+		{Command: "ssage fix"},
+	}
+	got := f.Apply(in)
+	want := []string{"make build", "make test", "go vet ./..."}
+	if strings.Join(commands(got), "|") != strings.Join(want, "|") {
+		t.Fatalf("Apply = %q, want %q", commands(got), want)
+	}
+	if got[1].Duration != 3*time.Second {
+		t.Errorf("duplicates should keep the latest run, got %v", got[1].Duration)
+	}
+
+	var none *Filter
+	if len(none.Apply(in)) != len(in) {
+		t.Error("a nil Filter should keep every entry")
+	}
+}
+
+func TestNewFilter_InvalidPattern(t *testing.T) {
+	if _, err := NewFilter(nil, []string{"("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestCommandName(t *testing.T) {
+	tests := map[string]string{
+		"ssage fix":                  "ssage",
+		"  FOO=bar BAZ=1 make":       "make",
+		"sudo -E ./bin/ssage.exe do": "ssage",
+		`C:\tools\ssage.exe fix`:     "ssage",
+		"time go test\n./...":        "go",
+		"echo a=b":                   "echo",
+		"":                           "",
+	}
+	for in, want := range tests {
+		if got := commandName(in); got != want {
+			t.Errorf("commandName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsSelf(t *testing.T) {
+	for command, want := range map[string]bool{
+		"ssage fix":                    true,
+		"DEBUG=1 ~/go/bin/ssage stats": true,
+		"sudo ssage init bash":         true,
+		"ls ssage":                     false,
+		"make build":                   false,
+	} {
+		if got := IsSelf(command); got != want {
+			t.Errorf("IsSelf(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
+
+// TestRecentFiltered_GrowsWindow verifies that the tail window grows until
+// limit entries survive the filter.
+func TestRecentFiltered_GrowsWindow(t *testing.T) {
+	home := isolate(t)
+	var b strings.Builder
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(&b, "make step-%d\n", i)
+	}
+	for i := 0; i < 200; i++ {
+		b.WriteString("ls\nssage fix\n")
+	}
+	writeFile(t, filepath.Join(home, ".bash_history"), b.String())
+	t.Setenv("SHELL", "/bin/bash")
+
+	f, err := NewFilter(DefaultIgnore, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := RecentFiltered(3, f)
+	if err != nil {
+		t.Fatalf("RecentFiltered: %v", err)
+	}
+	want := []string{"make step-2", "make step-3", "make step-4"}
+	if strings.Join(commands(got), "|") != strings.Join(want, "|") {
+		t.Fatalf("RecentFiltered = %q, want %q", commands(got), want)
+	}
+}
+
+// TestFilter_Where verifies that Where adds a predicate without changing the
+// receiver and works on a nil Filter.
+func TestFilter_Where(t *testing.T) {
+	in := []Entry{{Command: "docker ps"}, {Command: "ls"}, {Command: "docker ps"}, {Command: "make"}, {Command: "ssage fix"}}
+	docker := func(e Entry) bool { return strings.HasPrefix(e.Command, "docker") || e.Command == "ls" }
+
+	var none *Filter
+	if got := commands(none.Where(docker).Apply(in)); strings.Join(got, "|") != "docker ps|ls|docker ps" {
+		t.Errorf("nil.Where = %q", got)
+	}
+	f, _ := NewFilter(DefaultIgnore, nil)
+	if got := commands(f.Where(docker).Apply(in)); strings.Join(got, "|") != "docker ps" {
+		t.Errorf("Where = %q", got)
+	}
+	if got := commands(f.Apply(in)); strings.Join(got, "|") != "docker ps|make" {
+		t.Errorf("Where modified its receiver: %q", got)
+	}
+}
+
+// TestBefore verifies picking an entry by its line with preceding context.
+func TestBefore(t *testing.T) {
+	home := isolate(t)
+	var b strings.Builder
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintf(&b, "#%d\necho %d\n", 1700000000+i, i)
+	}
+	writeFile(t, filepath.Join(home, ".bash_history"), b.String())
+	t.Setenv("SHELL", "/bin/bash")
+
+	got, err := Before(2*10, 3) // "echo 10" starts on line 20
+	if err != nil {
+		t.Fatalf("Before: %v", err)
+	}
+	if want := "echo 7|echo 8|echo 9|echo 10"; strings.Join(commands(got), "|") != want {
+		t.Errorf("Before = %q, want %q", commands(got), want)
+	}
+	if _, err := Before(19, 3); err == nil {
+		t.Error("expected an error for a line inside an entry")
+	}
+	if _, err := Before(5000, 3); err == nil {
+		t.Error("expected an error for a line past the end")
+	}
+}
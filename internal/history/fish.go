@@ -0,0 +1,122 @@
+package history
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(fishSource{})
+}
+
+// fishSource reads fish history from $XDG_DATA_HOME/fish.
+type fishSource struct{}
+
+func (fishSource) Name() string { return ShellFish }
+
+func (fishSource) Active() bool { return shellIs("fish") }
+
+func (fishSource) Paths(home string) []string {
+	dir := filepath.Join(xdgDir("XDG_DATA_HOME", home, ".local/share"), "fish")
+	session := os.Getenv("fish_history")
+	if session == "" || session == "default" {
+		session = "fish"
+	}
+	return dedupe([]string{
+		filepath.Join(dir, session+"_history"),
+		filepath.Join(dir, "fish_history"),
+	})
+}
+
+func (fishSource) Read(path string) ([]Entry, error) { return readFile(path, ParseFish) }
+
+func (fishSource) OpenTail(path string) (Tail, error) {
+	return openTail(path, ParseFish, lineBoundary(func(_, line []byte) bool {
+		return bytes.HasPrefix(line, []byte("- cmd:"))
+	}))
+}
+
+func (fishSource) Hint() string {
+	return "  → Expected path: ~/.local/share/fish/fish_history\n" +
+		"  → Run a few commands in fish and try again"
+}
+
+// ParseFish parses fish's YAML-like history. Each record starts with a
+// "- cmd: <command>" line, followed by indented "when: <epoch>" and
+// optionally "paths:" with one "- <path>" item per line. Commands and paths
+// are stored escaped (see unescapeFish).
+func ParseFish(r io.Reader) ([]Entry, error) {
+	var (
+		entries []Entry
+		cur     *Entry
+		inPaths bool
+	)
+	flush := func() {
+		if cur != nil && strings.TrimSpace(cur.Command) != "" {
+			entries = append(entries, *cur)
+		}
+		cur = nil
+	}
+
+	sc := newScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if cmd, ok := strings.CutPrefix(line, "- cmd:"); ok {
+			flush()
+			cur = &Entry{Command: unescapeFish(strings.TrimPrefix(cmd, " ")), Shell: ShellFish, Line: n}
+			inPaths = false
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+
+		field := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(field, "when:"):
+			inPaths = false
+			if sec, err := strconv.ParseInt(strings.TrimSpace(field[len("when:"):]), 10, 64); err == nil {
+				cur.Timestamp = time.Unix(sec, 0)
+			}
+		case field == "paths:":
+			inPaths = true
+		case inPaths && strings.HasPrefix(field, "- "):
+			cur.Paths = append(cur.Paths, unescapeFish(field[2:]))
+		default:
+			inPaths = false
+		}
+	}
+	flush()
+	return entries, sc.Err()
+}
+
+// unescapeFish decodes fish's history escaping, which writes a newline as
+// `\n` and a backslash as `\\`. Other backslashes are kept as they are.
+func unescapeFish(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
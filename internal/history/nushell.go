@@ -0,0 +1,155 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(nushellSource{})
+}
+
+// nushellSource reads nushell history in either of its formats: plain text
+// (history.txt) or SQLite (history.sqlite3). SQLite history is queried
+// through the sqlite3 command-line tool so ssage needs no database driver.
+type nushellSource struct{}
+
+func (nushellSource) Name() string { return ShellNu }
+
+func (nushellSource) Active() bool { return shellIs("nu") }
+
+// Paths lists both formats; when both exist, the more recently written one
+// comes first since nushell only appends to the configured format.
+func (nushellSource) Paths(home string) []string {
+	dir := nushellConfigDir(home)
+	sqlite := filepath.Join(dir, "history.sqlite3")
+	text := filepath.Join(dir, "history.txt")
+	si, serr := os.Stat(sqlite)
+	ti, terr := os.Stat(text)
+	if serr == nil && terr == nil && ti.ModTime().After(si.ModTime()) {
+		return []string{text, sqlite}
+	}
+	return []string{sqlite, text}
+}
+
+func (nushellSource) Read(path string) ([]Entry, error) {
+	if isSQLite(path) {
+		return readNushellSQLite(path, 0)
+	}
+	return readFile(path, ParseNushell)
+}
+
+func (nushellSource) OpenTail(path string) (Tail, error) {
+	if isSQLite(path) {
+		return sqliteTail(path), nil
+	}
+	return openTail(path, ParseNushell, lineBoundary(anyLine))
+}
+
+// sqliteTail is the Tail of nushell's SQLite history: each Last runs a
+// query for the newest rows, whose IDs are already absolute.
+type sqliteTail string
+
+func (t sqliteTail) Last(n int) ([]Entry, error) { return readNushellSQLite(string(t), n) }
+func (sqliteTail) Base() (int, error)            { return 0, nil }
+func (sqliteTail) Close() error                  { return nil }
+
+// isSQLite reports whether path is nushell's SQLite history.
+func isSQLite(path string) bool {
+	return strings.HasSuffix(path, ".sqlite3") || strings.HasSuffix(path, ".db")
+}
+
+func (nushellSource) Hint() string {
+	return "  → Find nushell's history file with: $nu.history-path\n" +
+		"  → Run a few commands in nushell and try again"
+}
+
+// nushellConfigDir returns nushell's configuration directory.
+func nushellConfigDir(home string) string {
+	if runtime.GOOS == "linux" || os.Getenv("XDG_CONFIG_HOME") != "" {
+		return filepath.Join(xdgDir("XDG_CONFIG_HOME", home, ".config"), "nushell")
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "nushell")
+	}
+	return filepath.Join(home, ".config", "nushell")
+}
+
+// ParseNushell parses nushell's plain-text history: one command per line,
+// with newlines inside a command stored as the literal sequence `<\n>`.
+func ParseNushell(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	sc := newScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.ReplaceAll(sc.Text(), `<\n>`, "\n")
+		if strings.TrimSpace(line) != "" {
+			entries = append(entries, Entry{Command: line, Shell: ShellNu, Line: n})
+		}
+	}
+	return entries, sc.Err()
+}
+
+// nushellQuery selects the history rows in insertion order; %s is an
+// optional LIMIT clause applied to the newest rows.
+const nushellQuery = "SELECT * FROM (SELECT id, command_line, start_timestamp, duration_ms FROM history ORDER BY id DESC%s) ORDER BY id"
+
+// readNushellSQLite reads nushell's SQLite history with the sqlite3 CLI,
+// limited to the newest limit rows when limit > 0.
+func readNushellSQLite(path string, limit int) ([]Entry, error) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return nil, fmt.Errorf("reading nushell's SQLite history at '%s' requires the sqlite3 command-line tool.\n"+
+			"  → Install sqlite3 (e.g. apt install sqlite3, brew install sqlite)\n"+
+			"  → Or switch nushell to plain-text history: $env.config.history.file_format = \"plaintext\"", path)
+	}
+	var stderr bytes.Buffer
+	clause := ""
+	if limit > 0 {
+		clause = fmt.Sprintf(" LIMIT %d", limit)
+	}
+	c := exec.Command("sqlite3", "-readonly", "-json", path, fmt.Sprintf(nushellQuery, clause))
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3 failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseNushellRows(out)
+}
+
+// parseNushellRows decodes the JSON rows printed by `sqlite3 -json`.
+func parseNushellRows(out []byte) ([]Entry, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil // sqlite3 prints nothing for an empty result
+	}
+	var rows []struct {
+		ID         int    `json:"id"`
+		Command    string `json:"command_line"`
+		StartMs    *int64 `json:"start_timestamp"`
+		DurationMs *int64 `json:"duration_ms"`
+	}
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("could not decode sqlite3 output: %w", err)
+	}
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		if strings.TrimSpace(row.Command) == "" {
+			continue
+		}
+		e := Entry{Command: row.Command, Shell: ShellNu, Line: row.ID}
+		if row.StartMs != nil && *row.StartMs > 0 {
+			e.Timestamp = time.UnixMilli(*row.StartMs)
+		}
+		if row.DurationMs != nil && *row.DurationMs > 0 {
+			e.Duration = time.Duration(*row.DurationMs) * time.Millisecond
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -1,159 +1,121 @@
 package history
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"path/filepath"
-	"runtime"
-	"strings"
+	"sort"
 )
 
 // GetRecentCommands reads the last n commands from the shell history file.
-// It supports bash, zsh, and PowerShell (via PSReadLine).
+// It supports every registered Source (bash, zsh, fish, PowerShell,
+// nushell, xonsh).
 func GetRecentCommands(limit int) ([]string, error) {
-	historyFile, err := getHistoryFilePath()
+	entries, err := Recent(limit)
 	if err != nil {
 		return nil, err
 	}
-
-	file, err := os.Open(historyFile)
-	if err != nil {
-		return nil, fmt.Errorf("found history at '%s' but could not open it: %w\n  → Try: check permissions with 'ls -la %s'", historyFile, err, historyFile)
+	commands := make([]string, len(entries))
+	for i, e := range entries {
+		commands[i] = e.Command
 	}
-	defer file.Close()
+	return commands, nil
+}
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Strip zsh extended history format: ": <timestamp>:<elapsed>;<command>"
-		if strings.HasPrefix(line, ":") {
-			if parts := strings.SplitN(line, ";", 2); len(parts) == 2 {
-				line = parts[1]
-			}
-		}
-		if strings.TrimSpace(line) != "" {
-			lines = append(lines, line)
-		}
+// Recent returns the last limit entries of the user's shell history that
+// pass the configured Filter, oldest first. Their Line fields are not
+// absolute; use RecentFiltered for entries that Before can look up.
+func Recent(limit int) ([]Entry, error) {
+	f, err := ConfiguredFilter()
+	if err != nil {
+		return nil, err
 	}
+	return recent(limit, f, false)
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading history file: %w", err)
-	}
+// RecentFiltered returns the last limit entries kept by f (nil keeps all),
+// oldest first, read by the source Resolve selects. Sources that implement
+// TailReader only read the end of the file, growing the window until enough
+// entries survive the filter. Entry.Line holds the absolute line numbers
+// Before takes, which costs a pass over the rest of the file.
+func RecentFiltered(limit int, f *Filter) ([]Entry, error) {
+	return recent(limit, f, true)
+}
 
-	start := len(lines) - limit
-	if start < 0 {
-		start = 0
+// recent implements Recent and RecentFiltered.
+func recent(limit int, f *Filter, numbered bool) ([]Entry, error) {
+	var kept []Entry
+	_, err := readBack(limit, numbered, func(entries []Entry) bool {
+		kept = f.Apply(entries)
+		return len(kept) >= limit
+	})
+	if err != nil {
+		return nil, err
 	}
-	return lines[start:], nil
+	return tail(kept, limit), nil
 }
 
-// getHistoryFilePath detects the user's shell and returns the appropriate
-// history file path. Returns a descriptive, shell-specific error if not found.
-func getHistoryFilePath() (string, error) {
-	home, err := os.UserHomeDir()
+// Before returns the entry whose Line is line together with up to n entries
+// preceding it, oldest first. It reports an error if no entry starts at line.
+func Before(line, n int) ([]Entry, error) {
+	entries, err := readBack(n+1, true, func(entries []Entry) bool {
+		return len(entries) > 0 && entries[0].Line < line
+	})
 	if err != nil {
-		return "", fmt.Errorf("could not determine home directory: %w", err)
+		return nil, err
 	}
-
-	if runtime.GOOS == "windows" {
-		return windowsHistoryPath(home)
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Line >= line })
+	if i == len(entries) || entries[i].Line != line {
+		return nil, fmt.Errorf("no history entry with index %d", line)
 	}
-	return unixHistoryPath(home)
+	return tail(entries[:i+1], n+1), nil
 }
 
-// windowsHistoryPath checks PowerShell and bash-compatible history on Windows.
-func windowsHistoryPath(home string) (string, error) {
-	// Priority 1: PowerShell PSReadLine
-	appData := os.Getenv("APPDATA")
-	if appData != "" {
-		psHistory := filepath.Join(appData, "Microsoft", "Windows", "PowerShell", "PSReadLine", "ConsoleHost_history.txt")
-		if _, err := os.Stat(psHistory); err == nil {
-			return psHistory, nil
-		}
+// readBack reads the history of the source Resolve selects. For sources
+// that implement TailReader it reads a window of the newest entries,
+// starting at 4*n (at least 64) and growing it fourfold until done
+// reports true or the whole file has been read; other sources are read in
+// full. Entry.Line is made absolute only when numbered is set.
+func readBack(n int, numbered bool, done func([]Entry) bool) ([]Entry, error) {
+	src, path, err := Resolve()
+	if err != nil {
+		return nil, err
 	}
-
-	// Priority 2: Git Bash history (~/.bash_history)
-	bashPath := filepath.Join(home, ".bash_history")
-	if _, err := os.Stat(bashPath); err == nil {
-		return bashPath, nil
+	tr, ok := src.(TailReader)
+	if !ok {
+		entries, err := src.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s history: %w", src.Name(), err)
+		}
+		done(entries)
+		return entries, nil
 	}
-
-	// Priority 3: Zsh (rare on Windows but possible)
-	zshPath := filepath.Join(home, ".zsh_history")
-	if _, err := os.Stat(zshPath); err == nil {
-		return zshPath, nil
+	t, err := tr.OpenTail(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s history: %w", src.Name(), err)
 	}
-
-	return "", fmt.Errorf(
-		"no shell history found on Windows.\n" +
-			"  PowerShell users:\n" +
-			"    → Install PSReadLine: Install-Module PSReadLine -Force\n" +
-			"    → Restart PowerShell and run a few commands to create history\n" +
-			"  Git Bash users:\n" +
-			"    → Make sure Git for Windows is installed (git-scm.com)\n" +
-			"    → ~/.bash_history is created automatically after the first session",
-	)
-}
-
-// unixHistoryPath checks zsh, bash, fish or common fallback history on Linux/macOS.
-func unixHistoryPath(home string) (string, error) {
-	shell := os.Getenv("SHELL")
-
-	// Zsh
-	if strings.Contains(shell, "zsh") {
-		p := filepath.Join(home, ".zsh_history")
-		if _, err := os.Stat(p); err != nil {
-			return "", fmt.Errorf(
-				"zsh detected but ~/.zsh_history not found.\n" +
-					"  → Check it exists: ls -la ~/.zsh_history\n" +
-					"  → Make sure HISTFILE is set in your ~/.zshrc: echo $HISTFILE\n" +
-					"  → If empty, add: echo 'HISTFILE=~/.zsh_history' >> ~/.zshrc && source ~/.zshrc",
-			)
+	defer t.Close()
+	for window := max(4*n, 64); ; window *= 4 {
+		entries, err := t.Last(window)
+		if err == nil && numbered {
+			var base int
+			base, err = t.Base()
+			for i := range entries {
+				entries[i].Line += base
+			}
 		}
-		return p, nil
-	}
-
-	// Bash
-	if strings.Contains(shell, "bash") {
-		p := filepath.Join(home, ".bash_history")
-		if _, err := os.Stat(p); err != nil {
-			return "", fmt.Errorf(
-				"bash detected but ~/.bash_history not found.\n" +
-					"  → Check it exists: ls -la ~/.bash_history\n" +
-					"  → Make sure HISTFILE is set: echo 'HISTFILE=~/.bash_history' >> ~/.bashrc && source ~/.bashrc\n" +
-					"  → Run a few commands and then try again",
-			)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s history: %w", src.Name(), err)
 		}
-		return p, nil
-	}
-
-	// Fish shell — history stored as ~/.local/share/fish/fish_history (YAML-like)
-	if strings.Contains(shell, "fish") {
-		p := filepath.Join(home, ".local", "share", "fish", "fish_history")
-		if _, err := os.Stat(p); err != nil {
-			return "", fmt.Errorf(
-				"fish shell detected but history file not found.\n" +
-					"  → Expected path: ~/.local/share/fish/fish_history\n" +
-					"  → Run a few commands in fish and try again",
-			)
+		if done(entries) || len(entries) < window {
+			return entries, nil
 		}
-		return p, nil
 	}
+}
 
-	// Unknown $SHELL — try common files as fallback in order of popularity
-	for _, f := range []string{".zsh_history", ".bash_history", ".local/share/fish/fish_history"} {
-		p := filepath.Join(home, f)
-		if _, err := os.Stat(p); err == nil {
-			return p, nil
-		}
+// tail returns the last limit entries.
+func tail(entries []Entry, limit int) []Entry {
+	start := len(entries) - limit
+	if start < 0 {
+		start = 0
 	}
-
-	return "", fmt.Errorf(
-		"could not detect your shell or history file.\n" +
-			"  → Set the SHELL environment variable: export SHELL=$(which zsh)\n" +
-			"  → Or create the history file manually: touch ~/.bash_history\n" +
-			"  → Supported shells: bash, zsh, fish, PowerShell (Windows)",
-	)
+	return entries[start:]
 }
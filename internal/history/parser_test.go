@@ -1,14 +1,14 @@
 package history
 
 import (
-	"bufio"
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
-// parseFile is a test helper that runs the same parsing logic as GetRecentCommands
-// but on an explicit file path, bypassing OS/shell auto-detection.
+// parseFile is a test helper that runs the zsh parser on an explicit file
+// path, bypassing OS/shell auto-detection, and returns the last limit commands.
 func parseFile(path string, limit int) ([]string, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -16,27 +16,15 @@ func parseFile(path string, limit int) ([]string, error) {
 	}
 	defer f.Close()
 
-	var lines []string
-	sc := bufio.NewScanner(f)
-	for sc.Scan() {
-		line := sc.Text()
-		if strings.HasPrefix(line, ":") {
-			if parts := strings.SplitN(line, ";", 2); len(parts) == 2 {
-				line = parts[1]
-			}
-		}
-		if strings.TrimSpace(line) != "" {
-			lines = append(lines, line)
-		}
-	}
-	if err := sc.Err(); err != nil {
+	entries, err := ParseZsh(f)
+	if err != nil {
 		return nil, err
 	}
-	start := len(lines) - limit
-	if start < 0 {
-		start = 0
+	var lines []string
+	for _, e := range tail(entries, limit) {
+		lines = append(lines, e.Command)
 	}
-	return lines[start:], nil
+	return lines, nil
 }
 
 func writeTempHistory(t *testing.T, content string) string {
@@ -116,3 +104,211 @@ func TestParseFile_LimitGreaterThanLines(t *testing.T) {
 		t.Errorf("expected 2 commands, got %d", len(result))
 	}
 }
+
+// TestParseZsh_Metadata verifies extended history start time and duration.
+func TestParseZsh_Metadata(t *testing.T) {
+	entries, err := ParseZsh(strings.NewReader(": 1700000000:12;make build\nls\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %+v", len(entries), entries)
+	}
+	e := entries[0]
+	if e.Command != "make build" || !e.Timestamp.Equal(time.Unix(1700000000, 0)) || e.Duration != 12*time.Second {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if entries[1].Command != "ls" || !entries[1].Timestamp.IsZero() || entries[1].Line != 2 {
+		t.Errorf("plain line not parsed as-is: %+v", entries[1])
+	}
+}
+
+// TestParseBash_Timestamps verifies HISTTIMEFORMAT comment lines, including
+// multi-line commands and commands written before timestamps were enabled.
+func TestParseBash_Timestamps(t *testing.T) {
+	content := "ls\n#1700000000\ngit status\n#1700000005\nfor f in *; do\n  echo $f\ndone\n#notatime\n"
+	entries, err := ParseBash(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Command != "ls" || !entries[0].Timestamp.IsZero() {
+		t.Errorf("entries[0] = %+v", entries[0])
+	}
+	if entries[1].Command != "git status" || !entries[1].Timestamp.Equal(time.Unix(1700000000, 0)) || entries[1].Line != 3 {
+		t.Errorf("entries[1] = %+v", entries[1])
+	}
+	want := "for f in *; do\n  echo $f\ndone\n#notatime"
+	if entries[2].Command != want || entries[2].Line != 5 {
+		t.Errorf("entries[2] = %+v, want command %q", entries[2], want)
+	}
+}
+
+// fishHistoryFixture is a fish_history excerpt as written by fish 3.x,
+// including escaped multi-line commands, backslashes and recorded paths.
+const fishHistoryFixture = `- cmd: git push
+  when: 1700000000
+- cmd: cat src/main.go README.md
+  when: 1700000003
+  paths:
+    - src/main.go
+    - README.md
+- cmd: for f in *.log\n    gzip $f\nend
+  when: 1700000010
+- cmd: echo C:\\Users\\me
+  when: 1700000020
+- cmd: printf 'a\\nb'
+  when: 1700000030
+  paths:
+    - dir\\with\\backslash
+- cmd:
+  when: 1700000040
+`
+
+// TestParseFish_Records verifies that each fish record yields one entry with
+// its timestamp and paths, instead of one entry per YAML line.
+func TestParseFish_Records(t *testing.T) {
+	entries, err := ParseFish(strings.NewReader(fishHistoryFixture))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Entry{
+		{Command: "git push", Timestamp: time.Unix(1700000000, 0), Line: 1},
+		{Command: "cat src/main.go README.md", Timestamp: time.Unix(1700000003, 0), Line: 3, Paths: []string{"src/main.go", "README.md"}},
+		{Command: "for f in *.log\n    gzip $f\nend", Timestamp: time.Unix(1700000010, 0), Line: 8},
+		{Command: `echo C:\Users\me`, Timestamp: time.Unix(1700000020, 0), Line: 10},
+		{Command: `printf 'a\nb'`, Timestamp: time.Unix(1700000030, 0), Line: 12, Paths: []string{`dir\with\backslash`}},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i, w := range want {
+		got := entries[i]
+		if got.Command != w.Command || !got.Timestamp.Equal(w.Timestamp) || got.Line != w.Line || got.Shell != ShellFish {
+			t.Errorf("entries[%d] = %+v, want %+v", i, got, w)
+		}
+		if strings.Join(got.Paths, "|") != strings.Join(w.Paths, "|") {
+			t.Errorf("entries[%d].Paths = %q, want %q", i, got.Paths, w.Paths)
+		}
+	}
+}
+
+// TestParseFish_Limit verifies that the limit counts commands, not YAML lines.
+func TestParseFish_Limit(t *testing.T) {
+	entries, err := ParseFish(strings.NewReader(fishHistoryFixture))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	last := tail(entries, 2)
+	if len(last) != 2 || last[0].Command != `echo C:\Users\me` {
+		t.Errorf("tail(entries, 2) = %+v", last)
+	}
+}
+
+// TestUnescapeFish covers fish's escape sequences and stray backslashes.
+func TestUnescapeFish(t *testing.T) {
+	tests := map[string]string{
+		`plain`:         "plain",
+		`a\nb`:          "a\nb",
+		`a\\nb`:         `a\nb`,
+		`trailing\`:     `trailing\`,
+		`keep \t as-is`: `keep \t as-is`,
+	}
+	for in, want := range tests {
+		if got := unescapeFish(in); got != want {
+			t.Errorf("unescapeFish(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestParsePSReadLine_Continuation verifies backtick line continuations.
+func TestParsePSReadLine_Continuation(t *testing.T) {
+	content := "Get-Process\r\nGet-ChildItem `\r\n  -Recurse\r\ndir\r\n"
+	entries, err := ParsePSReadLine(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(entries), entries)
+	}
+	if entries[1].Command != "Get-ChildItem `\n  -Recurse" || entries[2].Line != 4 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+// metafy encodes s the way zsh writes it to its history file.
+func metafy(s string) string {
+	var b []byte
+	for _, c := range []byte(s) {
+		if c == 0 || (c >= 0x83 && c <= 0xa2) {
+			b = append(b, 0x83, c^0x20)
+			continue
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+// TestParseZsh_MultiLine verifies that backslash-continued lines are joined
+// into one entry, for plain and extended-history entries alike.
+func TestParseZsh_MultiLine(t *testing.T) {
+	content := ": 1700000000:3;for f in *.go; do\\\n  gofmt -l $f\\\ndone\n" +
+		"cat <<EOF > notes.txt\\\nfirst line\\\nEOF\n" +
+		": 1700000009:0;echo done\n"
+	entries, err := ParseZsh(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(entries), entries)
+	}
+	loop := entries[0]
+	if loop.Command != "for f in *.go; do\n  gofmt -l $f\ndone" || loop.Duration != 3*time.Second || loop.Line != 1 {
+		t.Errorf("loop entry = %+v", loop)
+	}
+	heredoc := entries[1]
+	if heredoc.Command != "cat <<EOF > notes.txt\nfirst line\nEOF" || heredoc.Line != 4 || !heredoc.Timestamp.IsZero() {
+		t.Errorf("heredoc entry = %+v", heredoc)
+	}
+	if entries[2].Command != "echo done" || entries[2].Line != 7 {
+		t.Errorf("entries[2] = %+v", entries[2])
+	}
+}
+
+// TestParseZsh_EscapedBackslash verifies that a trailing "\\" is a literal
+// backslash, not a continuation.
+func TestParseZsh_EscapedBackslash(t *testing.T) {
+	entries, err := ParseZsh(strings.NewReader("echo a\\\\\nls\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Command != `echo a\\` {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+// TestParseZsh_Metafied verifies that metafied non-ASCII bytes are decoded.
+func TestParseZsh_Metafied(t *testing.T) {
+	cmds := []string{"cd ~/Документы/日本語", "ls ~/Música/año", "echo 😀"}
+	var content string
+	for i, c := range cmds {
+		content += metafy(": 170000000" + string(rune('0'+i)) + ":0;" + c + "\n")
+	}
+	if strings.IndexByte(content, 0x83) < 0 {
+		t.Fatal("fixture was not metafied")
+	}
+	entries, err := ParseZsh(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != len(cmds) {
+		t.Fatalf("expected %d entries, got %d", len(cmds), len(entries))
+	}
+	for i, c := range cmds {
+		if entries[i].Command != c {
+			t.Errorf("entries[%d] = %q, want %q", i, entries[i].Command, c)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package history
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+func init() {
+	Register(pwshSource{})
+}
+
+// pwshSource reads PowerShell's PSReadLine history on Windows, Linux and
+// macOS.
+type pwshSource struct{}
+
+func (pwshSource) Name() string { return ShellPwsh }
+
+// Active is true for pwsh as login shell, and on Windows unless $SHELL points
+// elsewhere (e.g. Git Bash sets it to bash).
+func (pwshSource) Active() bool {
+	if shellIs("pwsh", "powershell") {
+		return true
+	}
+	return runtime.GOOS == "windows" && os.Getenv("SHELL") == ""
+}
+
+func (pwshSource) Paths(home string) []string {
+	var paths []string
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		paths = append(paths, filepath.Join(appData, "Microsoft", "Windows", "PowerShell", "PSReadLine", "ConsoleHost_history.txt"))
+	}
+	paths = append(paths, filepath.Join(xdgDir("XDG_DATA_HOME", home, ".local/share"), "powershell", "PSReadLine", "ConsoleHost_history.txt"))
+	return dedupe(paths)
+}
+
+func (pwshSource) Read(path string) ([]Entry, error) { return readFile(path, ParsePSReadLine) }
+
+func (pwshSource) OpenTail(path string) (Tail, error) {
+	return openTail(path, ParsePSReadLine, lineBoundary(func(prev, _ []byte) bool {
+		return !bytes.HasSuffix(prev, []byte("`"))
+	}))
+}
+
+func (pwshSource) Hint() string {
+	return "  → Install PSReadLine: Install-Module PSReadLine -Force\n" +
+		"  → Restart PowerShell and run a few commands to create history"
+}
+
+// ParsePSReadLine parses PowerShell's PSReadLine history, which stores one
+// command per line; a trailing backtick continues the command on the next
+// line. It records no timestamps.
+func ParsePSReadLine(r io.Reader) ([]Entry, error) {
+	var (
+		entries []Entry
+		cur     *Entry
+	)
+	sc := newScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if cur == nil {
+			cur = &Entry{Shell: ShellPwsh, Line: n}
+		} else {
+			cur.Command += "\n"
+		}
+		if strings.HasSuffix(line, "`") {
+			cur.Command += line
+			continue
+		}
+		cur.Command += line
+		if strings.TrimSpace(cur.Command) != "" {
+			entries = append(entries, *cur)
+		}
+		cur = nil
+	}
+	if cur != nil && strings.TrimSpace(cur.Command) != "" {
+		entries = append(entries, *cur)
+	}
+	return entries, sc.Err()
+}
@@ -0,0 +1,233 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shell-sage/internal/config"
+)
+
+// Source is a shell history backend: it knows whether it is the user's
+// shell, where that shell keeps its history and how to parse it. Each
+// source registers itself from an init function:
+//
+//	func init() {
+//	    history.Register(zshSource{})
+//	}
+type Source interface {
+	// Name returns the unique identifier of this source (e.g. "zsh"). It is
+	// the value accepted by the history.source config key.
+	Name() string
+
+	// Active reports whether the environment indicates this is the user's
+	// shell, typically from $SHELL.
+	Active() bool
+
+	// Paths returns the candidate history locations for home, most specific
+	// first (e.g. $HISTFILE before the default file). The first one that
+	// exists is used.
+	Paths(home string) []string
+
+	// Read parses every entry at path, oldest first.
+	Read(path string) ([]Entry, error)
+
+	// Hint explains how to get a history file when none of Paths exists.
+	Hint() string
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Source)
+)
+
+// fallbackOrder is the order in which sources are probed when no source is
+// configured and none is active, by popularity.
+var fallbackOrder = []string{ShellZsh, ShellBash, ShellFish, ShellPwsh, ShellNu, ShellXonsh}
+
+// Register adds a history source. It panics if the same name is registered
+// twice, mirroring provider.Register.
+func Register(s Source) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := registry[s.Name()]; dup {
+		panic(fmt.Sprintf("history: source %q already registered", s.Name()))
+	}
+	registry[s.Name()] = s
+}
+
+// Sources returns a sorted list of all registered source names.
+func Sources() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the source registered under name.
+func Lookup(name string) (Source, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := registry[name]
+	return s, ok
+}
+
+// Resolve picks the history source and file to read, applying the priority
+// chain: SSAGE_HISTORY_SOURCE env > history.source config > the active
+// shell > the first source whose history file exists. It returns a
+// descriptive, shell-specific error if no history is found.
+func Resolve() (Source, string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, "", fmt.Errorf("could not determine home directory: %w", err)
+	}
+
+	if name := configuredSource(); name != "" {
+		s, ok := Lookup(name)
+		if !ok {
+			return nil, "", fmt.Errorf("unknown history source %q (available: %s)", name, strings.Join(Sources(), ", "))
+		}
+		return locate(s, home)
+	}
+
+	ordered := orderedSources()
+	for _, s := range ordered {
+		if s.Active() {
+			return locate(s, home)
+		}
+	}
+	for _, s := range ordered {
+		if path, ok := firstExisting(s.Paths(home)); ok {
+			return s, path, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf(
+		"could not detect your shell or history file.\n"+
+			"  → Set the SHELL environment variable: export SHELL=$(which zsh)\n"+
+			"  → Or pick a source explicitly: ssage config set history.source bash\n"+
+			"  → Supported shells: %s", strings.Join(Sources(), ", "),
+	)
+}
+
+// configuredSource returns the explicitly selected source name, if any.
+func configuredSource() string {
+	if env := os.Getenv("SSAGE_HISTORY_SOURCE"); env != "" {
+		return env
+	}
+	if cfg, err := config.Load(); err == nil {
+		return cfg.History.Source
+	}
+	return ""
+}
+
+// locate returns the first existing history location of s.
+func locate(s Source, home string) (Source, string, error) {
+	paths := s.Paths(home)
+	if path, ok := firstExisting(paths); ok {
+		return s, path, nil
+	}
+	return nil, "", fmt.Errorf("%s history not found (looked in: %s).\n%s",
+		s.Name(), strings.Join(paths, ", "), s.Hint())
+}
+
+// orderedSources returns the registered sources in fallbackOrder, followed
+// by any others sorted by name.
+func orderedSources() []Source {
+	var out []Source
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, fallbackOrder...), Sources()...) {
+		if s, ok := Lookup(name); ok && !seen[name] {
+			seen[name] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// firstExisting returns the first path that exists.
+func firstExisting(paths []string) (string, bool) {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// dedupe drops empty and repeated paths, keeping the first occurrence.
+func dedupe(paths []string) []string {
+	out := paths[:0]
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		if p != "" && !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// shellIs reports whether $SHELL names the given shell binary.
+func shellIs(names ...string) bool {
+	base := filepath.Base(os.Getenv("SHELL"))
+	base = strings.TrimSuffix(base, ".exe")
+	for _, n := range names {
+		if base == n {
+			return true
+		}
+	}
+	return false
+}
+
+// histfile returns $HISTFILE when the shell that exported it is active.
+func histfile(active bool) string {
+	if !active {
+		return ""
+	}
+	return expandHome(os.Getenv("HISTFILE"))
+}
+
+// xdgDir returns $<env> or home/<fallback> when it is unset.
+func xdgDir(env, home, fallback string) string {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(home, fallback)
+}
+
+// expandHome expands a leading "~/" in p.
+func expandHome(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return p
+}
+
+// readFile opens path and parses it with parse.
+func readFile(path string, parse Parser) ([]Entry, error) {
+	f, err := openHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+// openHistory opens the history file at path.
+func openHistory(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("found history at '%s' but could not open it: %w\n  → Try: check permissions with 'ls -la %s'", path, err, path)
+	}
+	return f, nil
+}
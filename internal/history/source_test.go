@@ -0,0 +1,174 @@
+package history
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isolate points HOME and the XDG/history variables at a temp directory so
+// Resolve only sees the files a test creates.
+func isolate(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	for _, env := range []string{"SHELL", "HISTFILE", "ZDOTDIR", "XDG_STATE_HOME", "XDG_DATA_HOME",
+		"XDG_CONFIG_HOME", "APPDATA", "XONSH_DATA_DIR", "fish_history", "SSAGE_HISTORY_SOURCE"} {
+		t.Setenv(env, "")
+	}
+	return home
+}
+
+func writeFile(t testing.TB, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestResolve_HISTFILE verifies that $HISTFILE of the active shell wins over
+// the default location.
+func TestResolve_HISTFILE(t *testing.T) {
+	home := isolate(t)
+	custom := filepath.Join(home, ".local", "state", "zsh", "hist")
+	writeFile(t, custom, "ls\n")
+	writeFile(t, filepath.Join(home, ".zsh_history"), "pwd\n")
+	t.Setenv("SHELL", "/usr/bin/zsh")
+	t.Setenv("HISTFILE", custom)
+
+	src, path, err := Resolve()
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if src.Name() != ShellZsh || path != custom {
+		t.Errorf("Resolve = %s %s, want zsh %s", src.Name(), path, custom)
+	}
+
+	// $HISTFILE belongs to the login shell and is ignored for other sources.
+	t.Setenv("SHELL", "/bin/fish")
+	if _, _, err := Resolve(); err == nil || !strings.Contains(err.Error(), "fish history not found") {
+		t.Errorf("expected a fish-specific error, got %v", err)
+	}
+}
+
+// TestResolve_Override verifies the SSAGE_HISTORY_SOURCE override and the
+// fallback to any existing history when $SHELL is unknown.
+func TestResolve_Override(t *testing.T) {
+	home := isolate(t)
+	writeFile(t, filepath.Join(home, ".bash_history"), "ls\n")
+	writeFile(t, filepath.Join(home, ".config", "nushell", "history.txt"), "ls\n")
+
+	src, _, err := Resolve()
+	if err != nil || src.Name() != ShellBash {
+		t.Fatalf("fallback Resolve = %v, %v; want bash", src, err)
+	}
+
+	t.Setenv("SSAGE_HISTORY_SOURCE", ShellNu)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	src, path, err := Resolve()
+	if err != nil || src.Name() != ShellNu || filepath.Base(path) != "history.txt" {
+		t.Fatalf("override Resolve = %v %s, %v; want nushell history.txt", src, path, err)
+	}
+
+	t.Setenv("SSAGE_HISTORY_SOURCE", "tcsh")
+	if _, _, err := Resolve(); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
+
+// TestResolve_PwshOnLinux verifies the PSReadLine location used by
+// PowerShell on Linux and macOS.
+func TestResolve_PwshOnLinux(t *testing.T) {
+	home := isolate(t)
+	p := filepath.Join(home, ".local", "share", "powershell", "PSReadLine", "ConsoleHost_history.txt")
+	writeFile(t, p, "Get-Process\n")
+	t.Setenv("SHELL", "/opt/microsoft/powershell/7/pwsh")
+
+	src, path, err := Resolve()
+	if err != nil || src.Name() != ShellPwsh || path != p {
+		t.Fatalf("Resolve = %v %s, %v; want pwsh %s", src, path, err, p)
+	}
+}
+
+// TestParseNushell verifies the plain-text format's newline escaping.
+func TestParseNushell(t *testing.T) {
+	entries, err := ParseNushell(strings.NewReader("ls | where size > 1kb\nfor x in [1 2] {<\\n>  print $x<\\n>}\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Command != "for x in [1 2] {\n  print $x\n}" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+// TestReadNushellSQLite reads a database created with the sqlite3 CLI.
+func TestReadNushellSQLite(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	db := filepath.Join(t.TempDir(), "history.sqlite3")
+	schema := "CREATE TABLE history (id INTEGER PRIMARY KEY, command_line TEXT, start_timestamp INTEGER, duration_ms INTEGER);" +
+		"INSERT INTO history VALUES (1, 'cargo build', 1700000000000, 12000), (2, 'ls', NULL, NULL);"
+	if out, err := exec.Command("sqlite3", db, schema).CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3: %v: %s", err, out)
+	}
+
+	entries, err := nushellSource{}.Read(db)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", entries)
+	}
+	e := entries[0]
+	if e.Command != "cargo build" || !e.Timestamp.Equal(time.UnixMilli(1700000000000)) || e.Duration != 12*time.Second {
+		t.Errorf("entries[0] = %+v", e)
+	}
+	if entries[1].Command != "ls" || !entries[1].Timestamp.IsZero() {
+		t.Errorf("entries[1] = %+v", entries[1])
+	}
+
+	tl, err := nushellSource{}.OpenTail(db)
+	if err != nil {
+		t.Fatalf("OpenTail: %v", err)
+	}
+	defer tl.Close()
+	last, err := tl.Last(1)
+	if err != nil || len(last) != 1 || last[0].Command != "ls" || last[0].Line != 2 {
+		t.Errorf("Last(1) = %+v, %v", last, err)
+	}
+}
+
+// TestReadXonsh verifies that session files in history_json are merged in
+// start-time order.
+func TestReadXonsh(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "xonsh-b.json"),
+		`{"locs": [], "data": {"cmds": [{"inp": "git status\n", "rtn": 0, "ts": [1700000005.0, 1700000005.5]}]}}`)
+	writeFile(t, filepath.Join(dir, "xonsh-a.json"),
+		`{"data": {"cmds": [{"inp": "ls\n", "rtn": 0, "ts": [1700000000.0, 1700000001.0]}, {"inp": "for i in range(2):\n    print(i)\n", "ts": [1700000010.0, 1700000010.1]}]}}`)
+	writeFile(t, filepath.Join(dir, "xonsh-c.json"), `{"data": {"cmds": [`) // session being written
+
+	entries, err := xonshSource{}.Read(dir)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Command)
+	}
+	want := []string{"ls", "git status", "for i in range(2):\n    print(i)"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+	if entries[0].Duration != time.Second || entries[2].Line != 3 {
+		t.Errorf("unexpected metadata: %+v", entries)
+	}
+}
@@ -0,0 +1,172 @@
+package history
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// TailReader is implemented by sources that can read just the newest
+// entries of a history file without parsing all of it.
+type TailReader interface {
+	// OpenTail opens the history at path for reading from the end.
+	OpenTail(path string) (Tail, error)
+}
+
+// Tail reads the newest entries of a history file.
+type Tail interface {
+	// Last returns the last n entries, oldest first. It reads further back
+	// only as far as needed, keeping what earlier calls read. Entry.Line
+	// counts from the first line Last parsed; add Base to make it absolute.
+	Last(n int) ([]Entry, error)
+
+	// Base returns the number of lines before those the latest Last call
+	// parsed. Computing it may read the rest of the file.
+	Base() (int, error)
+
+	Close() error
+}
+
+// tailChunk is the size of the first block read from the end of the file.
+// Each further block is twice as large as the one before.
+const tailChunk = 64 << 10
+
+// boundaryFunc returns the offset of the first record start in buf, which
+// begins at an arbitrary byte of the file, or -1 if buf holds none.
+type boundaryFunc func(buf []byte) int
+
+// tailFile is the Tail of a line-based history file. It reads the file
+// backwards in growing blocks and parses the text after the first record
+// boundary.
+type tailFile struct {
+	f        *os.File
+	parse    Parser
+	boundary boundaryFunc
+
+	buf    []byte // the file from off to its end
+	off    int64
+	chunk  int64 // size of the next block
+	start  int   // offset in buf of the text the latest Last parsed
+	prefix int   // newlines before off, or -1 until Base counts them
+}
+
+// openTail opens path for reading from the end with parse, using boundary
+// to find where records start.
+func openTail(path string, parse Parser, boundary boundaryFunc) (Tail, error) {
+	f, err := openHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &tailFile{f: f, parse: parse, boundary: boundary, off: info.Size(), chunk: tailChunk, prefix: -1}, nil
+}
+
+func (t *tailFile) Last(n int) ([]Entry, error) {
+	for {
+		start := 0
+		if t.off > 0 {
+			start = t.boundary(t.buf)
+		}
+		if start >= 0 {
+			entries, err := t.parse(bytes.NewReader(t.buf[start:]))
+			if err != nil {
+				return nil, err
+			}
+			if len(entries) >= n || t.off == 0 {
+				t.start = start
+				return tail(entries, n), nil
+			}
+		}
+		if err := t.grow(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// grow reads the block before buf.
+func (t *tailFile) grow() error {
+	size := min(t.chunk, t.off)
+	t.off -= size
+	block := make([]byte, size, int(size)+len(t.buf))
+	if _, err := t.f.ReadAt(block, t.off); err != nil && err != io.EOF {
+		return err
+	}
+	if t.prefix >= 0 {
+		t.prefix -= bytes.Count(block, []byte{'\n'})
+	}
+	t.buf = append(block, t.buf...)
+	t.chunk *= 2
+	return nil
+}
+
+// Base counts the newlines before buf once; later calls only adjust the
+// count for the blocks read since.
+func (t *tailFile) Base() (int, error) {
+	if t.prefix < 0 {
+		n, err := countLines(t.f, t.off)
+		if err != nil {
+			return 0, err
+		}
+		t.prefix = n
+	}
+	return t.prefix + bytes.Count(t.buf[:t.start], []byte{'\n'}), nil
+}
+
+func (t *tailFile) Close() error { return t.f.Close() }
+
+// countLines counts the newlines in the first size bytes of f.
+func countLines(f *os.File, size int64) (int, error) {
+	block := make([]byte, tailChunk)
+	lines := 0
+	for pos := int64(0); pos < size; {
+		want := int64(len(block))
+		if size-pos < want {
+			want = size - pos
+		}
+		got, err := f.ReadAt(block[:want], pos)
+		lines += bytes.Count(block[:got], []byte{'\n'})
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if got == 0 {
+			break
+		}
+		pos += int64(got)
+	}
+	return lines, nil
+}
+
+// lineBoundary returns a boundaryFunc for line-based formats. It skips the
+// (possibly partial) first line of buf and returns the first line for which
+// isStart reports a record start given the preceding line. Only the end of
+// prev is guaranteed to be complete.
+func lineBoundary(isStart func(prev, line []byte) bool) boundaryFunc {
+	return func(buf []byte) int {
+		nl := bytes.IndexByte(buf, '\n')
+		if nl < 0 {
+			return -1
+		}
+		prev := bytes.TrimSuffix(buf[:nl], []byte("\r"))
+		for pos := nl + 1; pos < len(buf); {
+			end := bytes.IndexByte(buf[pos:], '\n')
+			if end < 0 {
+				// The last line is complete only because it ends the file.
+				end = len(buf) - pos
+			}
+			line := bytes.TrimSuffix(buf[pos:pos+end], []byte("\r"))
+			if isStart(prev, line) {
+				return pos
+			}
+			prev = line
+			pos += end + 1
+		}
+		return -1
+	}
+}
+
+// anyLine treats every line as a record start.
+func anyLine(prev, line []byte) bool { return true }
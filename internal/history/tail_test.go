@@ -0,0 +1,156 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// genHistory writes a history file of n entries in the given format. Every
+// 50th entry spans several lines and every 1000th is a long pasted line, so
+// records regularly straddle the tail reader's chunk boundaries.
+func genHistory(tb testing.TB, shell string, n int) string {
+	tb.Helper()
+	var b strings.Builder
+	long := `{"payload": "` + strings.Repeat("x", 70<<10) + `"}`
+	for i := 0; i < n; i++ {
+		ts := 1700000000 + i
+		cmd := fmt.Sprintf("echo command-%d", i)
+		multi := i%50 == 0
+		if i%1000 == 999 {
+			cmd = "curl -d '" + long + "' localhost"
+		}
+		switch shell {
+		case ShellZsh:
+			if multi {
+				cmd = fmt.Sprintf("for i in 1 2; do\\\n  echo %d\\\ndone", i)
+			}
+			fmt.Fprintf(&b, ": %d:%d;%s\n", ts, i%7, cmd)
+		case ShellBash:
+			if multi {
+				cmd = fmt.Sprintf("for i in 1 2; do\n  echo %d\ndone", i)
+			}
+			fmt.Fprintf(&b, "#%d\n%s\n", ts, cmd)
+		case ShellFish:
+			if multi {
+				cmd = fmt.Sprintf(`for i in 1 2\n  echo %d\nend`, i)
+			}
+			fmt.Fprintf(&b, "- cmd: %s\n  when: %d\n", cmd, ts)
+			if i%3 == 0 {
+				b.WriteString("  paths:\n    - src/main.go\n")
+			}
+		case ShellPwsh:
+			if multi {
+				cmd = fmt.Sprintf("Get-ChildItem `\n  -Filter %d", i)
+			}
+			b.WriteString(cmd + "\n")
+		case ShellNu:
+			if multi {
+				cmd = fmt.Sprintf(`for x in [1 2] {<\n>  print %d<\n>}`, i)
+			}
+			b.WriteString(cmd + "\n")
+		}
+	}
+	path := filepath.Join(tb.TempDir(), shell+"_history")
+	writeFile(tb, path, b.String())
+	return path
+}
+
+// TestTail_MatchesFullRead verifies that reading from the end yields the
+// same entries, with the same absolute line numbers, as parsing the whole
+// file, for every line-based format. One Tail serves the growing windows,
+// as in readBack.
+func TestTail_MatchesFullRead(t *testing.T) {
+	for _, shell := range []string{ShellZsh, ShellBash, ShellFish, ShellPwsh, ShellNu} {
+		t.Run(shell, func(t *testing.T) {
+			src, _ := Lookup(shell)
+			tr := src.(TailReader)
+			path := genHistory(t, shell, 5000)
+
+			all, err := src.Read(path)
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if len(all) != 5000 {
+				t.Fatalf("Read returned %d entries, want 5000", len(all))
+			}
+			tl, err := tr.OpenTail(path)
+			if err != nil {
+				t.Fatalf("OpenTail: %v", err)
+			}
+			defer tl.Close()
+			for _, n := range []int{1, 10, 51, 999, 1001, 4000, 5000, 6000} {
+				got, err := tl.Last(n)
+				if err != nil {
+					t.Fatalf("Last(%d): %v", n, err)
+				}
+				base, err := tl.Base()
+				if err != nil {
+					t.Fatalf("Base: %v", err)
+				}
+				for i := range got {
+					got[i].Line += base
+				}
+				if want := tail(all, n); !reflect.DeepEqual(got, want) {
+					t.Fatalf("Last(%d) differs from full read: got %d entries, want %d (first got %+v, want %+v)",
+						n, len(got), len(want), first(got), first(want))
+				}
+			}
+		})
+	}
+}
+
+func first(entries []Entry) Entry {
+	if len(entries) == 0 {
+		return Entry{}
+	}
+	e := entries[0]
+	if len(e.Command) > 40 {
+		e.Command = e.Command[:40] + "…"
+	}
+	return e
+}
+
+// TestParse_NoLineLimit verifies that lines longer than bufio.Scanner's
+// limits are parsed instead of aborting the read.
+func TestParse_NoLineLimit(t *testing.T) {
+	huge := "echo '" + strings.Repeat("j", 3<<20) + "'"
+	entries, err := ParseZsh(strings.NewReader("ls\n: 1700000000:0;" + huge + "\npwd\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 || entries[1].Command != huge {
+		t.Fatalf("expected the 3 MiB command to be parsed intact, got %d entries", len(entries))
+	}
+}
+
+// BenchmarkRecent compares parsing a whole 200k-entry zsh history with
+// reading only its tail, the way `ssage fix` asks for the last 10 commands.
+func BenchmarkRecent(b *testing.B) {
+	path := genHistory(b, ShellZsh, 200000)
+	src := zshSource{}
+
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			entries, err := src.Read(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = tail(entries, 10)
+		}
+	})
+	b.Run("tail", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tl, err := src.OpenTail(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := tl.Last(10); err != nil {
+				b.Fatal(err)
+			}
+			tl.Close()
+		}
+	})
+}
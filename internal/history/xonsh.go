@@ -0,0 +1,111 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(xonshSource{})
+}
+
+// xonshSource reads xonsh's JSON history backend, which writes one file per
+// session into a history_json directory.
+type xonshSource struct{}
+
+func (xonshSource) Name() string { return ShellXonsh }
+
+func (xonshSource) Active() bool { return shellIs("xonsh") }
+
+func (xonshSource) Paths(home string) []string {
+	var paths []string
+	if dir := os.Getenv("XONSH_DATA_DIR"); dir != "" {
+		paths = append(paths, filepath.Join(expandHome(dir), "history_json"))
+	}
+	paths = append(paths, filepath.Join(xdgDir("XDG_DATA_HOME", home, ".local/share"), "xonsh", "history_json"))
+	return dedupe(paths)
+}
+
+// Read parses a single session file, or every session file when path is the
+// history directory, merging them in start-time order.
+func (xonshSource) Read(path string) ([]Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readFile(path, ParseXonsh)
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, f := range files {
+		session, err := readFile(f, ParseXonsh)
+		if err != nil {
+			continue // a session still being written may be incomplete
+		}
+		entries = append(entries, session...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+	for i := range entries {
+		entries[i].Line = i + 1
+	}
+	return entries, nil
+}
+
+func (xonshSource) Hint() string {
+	return "  → Make sure xonsh uses the JSON backend: $XONSH_HISTORY_BACKEND = 'json'\n" +
+		"  → Run a few commands in xonsh and try again"
+}
+
+// ParseXonsh parses one xonsh JSON history session file. Each command has
+// its input and [start, end] timestamps in seconds:
+//
+//	{"data": {"cmds": [{"inp": "ls\n", "rtn": 0, "ts": [1700000000.1, 1700000000.4]}]}}
+func ParseXonsh(r io.Reader) ([]Entry, error) {
+	var file struct {
+		Data struct {
+			Cmds []struct {
+				Inp string    `json:"inp"`
+				TS  []float64 `json:"ts"`
+			} `json:"cmds"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid xonsh history file: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(file.Data.Cmds))
+	for i, c := range file.Data.Cmds {
+		cmd := strings.TrimRight(c.Inp, "\n")
+		if strings.TrimSpace(cmd) == "" {
+			continue
+		}
+		e := Entry{Command: cmd, Shell: ShellXonsh, Line: i + 1}
+		if len(c.TS) > 0 {
+			e.Timestamp = floatTime(c.TS[0])
+			if len(c.TS) > 1 && c.TS[1] > c.TS[0] {
+				e.Duration = time.Duration((c.TS[1] - c.TS[0]) * float64(time.Second))
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// floatTime converts fractional Unix seconds to a time.Time.
+func floatTime(sec float64) time.Time {
+	whole := int64(sec)
+	return time.Unix(whole, int64((sec-float64(whole))*1e9))
+}
@@ -0,0 +1,131 @@
+package history
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(zshSource{})
+}
+
+// zshSource reads zsh history from $HISTFILE or the usual default locations.
+type zshSource struct{}
+
+func (zshSource) Name() string { return ShellZsh }
+
+func (zshSource) Active() bool { return shellIs("zsh") }
+
+func (s zshSource) Paths(home string) []string {
+	zdotdir := os.Getenv("ZDOTDIR")
+	if zdotdir == "" {
+		zdotdir = home
+	}
+	return dedupe([]string{
+		histfile(s.Active()),
+		filepath.Join(zdotdir, ".zsh_history"),
+		filepath.Join(home, ".zsh_history"),
+		filepath.Join(home, ".histfile"),
+		filepath.Join(xdgDir("XDG_STATE_HOME", home, ".local/state"), "zsh", "history"),
+	})
+}
+
+func (zshSource) Read(path string) ([]Entry, error) { return readFile(path, ParseZsh) }
+
+func (zshSource) OpenTail(path string) (Tail, error) {
+	return openTail(path, ParseZsh, lineBoundary(func(prev, line []byte) bool {
+		return !zshContinues(prev)
+	}))
+}
+
+func (zshSource) Hint() string {
+	return "  → Check where zsh writes history: echo $HISTFILE\n" +
+		"  → If empty, add to ~/.zshrc: HISTFILE=~/.zsh_history SAVEHIST=10000\n" +
+		"  → If it is set but not exported, add: export HISTFILE"
+}
+
+// ParseZsh parses zsh history. Extended-history lines of the form
+// ": <start>:<elapsed>;<command>" yield a timestamp and duration; plain lines
+// yield the command only.
+//
+// zsh writes each newline inside a command (continuations, heredocs, loops)
+// as a backslash at the end of the physical line; those lines are joined
+// back into one entry. Bytes are un-metafied (see unmetafy) so non-ASCII
+// commands and paths come through intact.
+func ParseZsh(r io.Reader) ([]Entry, error) {
+	var (
+		entries []Entry
+		buf     []byte
+		first   int
+	)
+	sc := newScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if len(buf) == 0 {
+			first = n
+		}
+		if zshContinues(line) {
+			buf = append(buf, line[:len(line)-1]...)
+			buf = append(buf, '\n')
+			continue
+		}
+		buf = append(buf, line...)
+		if e, ok := parseZshEntry(string(unmetafy(buf)), first); ok {
+			entries = append(entries, e)
+		}
+		buf = buf[:0]
+	}
+	// A file cut off mid-command still yields what was written.
+	if len(buf) > 0 {
+		if e, ok := parseZshEntry(strings.TrimSuffix(string(unmetafy(buf)), "\n"), first); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries, sc.Err()
+}
+
+// parseZshEntry builds an entry from one logical history line.
+func parseZshEntry(line string, n int) (Entry, bool) {
+	e := Entry{Command: line, Shell: ShellZsh, Line: n}
+	if meta, cmd, ok := strings.Cut(line, ";"); ok && strings.HasPrefix(meta, ": ") {
+		if start, elapsed, ok := strings.Cut(meta[2:], ":"); ok {
+			if sec, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64); err == nil {
+				e.Command = cmd
+				e.Timestamp = time.Unix(sec, 0)
+				if d, err := strconv.ParseInt(strings.TrimSpace(elapsed), 10, 64); err == nil {
+					e.Duration = time.Duration(d) * time.Second
+				}
+			}
+		}
+	}
+	return e, strings.TrimSpace(e.Command) != ""
+}
+
+// zshContinues reports whether a physical history line continues on the
+// next one: it ends in a single backslash (an escaped "\\" does not count).
+func zshContinues(line []byte) bool {
+	n := len(line)
+	return n > 0 && line[n-1] == '\\' && (n < 2 || line[n-2] != '\\')
+}
+
+// zshMeta is the byte zsh prefixes to "metafied" bytes in its history file;
+// the byte that follows is the original XOR 0x20.
+const zshMeta = 0x83
+
+// unmetafy reverses zsh's metafication in place and returns the result.
+func unmetafy(b []byte) []byte {
+	out := b[:0]
+	for i := 0; i < len(b); i++ {
+		if b[i] == zshMeta && i+1 < len(b) {
+			i++
+			out = append(out, b[i]^0x20)
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package journal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shells lists the shells Hook can generate an integration snippet for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Hook returns the shell integration snippet for shell. exe is the path of
+// the ssage binary the hook should invoke. When captureStderr is true the
+// bash and zsh hooks tee each command's stderr to a temp file so its tail
+// can be stored in the journal; fish does not support this. The file is
+// created private with mktemp when the shell starts and removed when it
+// exits. tee writes to it through a descriptor the shell opened, so a tee
+// still starting up cannot recreate it after the removal.
+func Hook(shell, exe string, captureStderr bool) (string, error) {
+	var tmpl string
+	switch shell {
+	case "bash":
+		tmpl = bashHook
+	case "zsh":
+		tmpl = zshHook
+	case "fish":
+		if captureStderr {
+			return "", fmt.Errorf("stderr capture is not supported for fish")
+		}
+		tmpl = fishHook
+	default:
+		return "", fmt.Errorf("unsupported shell %q (supported: %s)", shell, strings.Join(Shells, ", "))
+	}
+
+	capture := "0"
+	if captureStderr {
+		capture = "1"
+	}
+	r := strings.NewReplacer(
+		"{{EXE}}", shellQuote(exe),
+		"{{CAPTURE}}", capture,
+	)
+	return r.Replace(tmpl), nil
+}
+
+// shellQuote single-quotes s for POSIX shells and fish.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+const bashHook = `# ssage shell integration for bash — add to ~/.bashrc:
+#   eval "$(ssage init bash)"
+# Records exit code, duration and cwd of each command for 'ssage fix'.
+# Note: this installs a DEBUG trap and replaces any existing one; with
+# --capture-stderr it also replaces the EXIT trap.
+__ssage_exe={{EXE}}
+__ssage_capture={{CAPTURE}}
+__ssage_errfile=
+if [ "$__ssage_capture" = 1 ]; then
+  if __ssage_errfile=$(umask 077; mktemp "${XDG_RUNTIME_DIR:-${TMPDIR:-/tmp}}/ssage-stderr.XXXXXX"); then
+    trap 'rm -f "$__ssage_errfile"' EXIT
+  else
+    __ssage_capture=0
+  fi
+fi
+__ssage_armed=
+__ssage_cmd=
+
+# __ssage_histnum sets __ssage_hist to the newest history line and
+# __ssage_num to its number.
+__ssage_histnum() {
+  local n
+  __ssage_hist=$(HISTTIMEFORMAT= builtin history 1)
+  n=${__ssage_hist#"${__ssage_hist%%[! ]*}"}
+  __ssage_num=${n%%[!0-9]*}
+}
+
+__ssage_preexec() {
+  [ -n "$__ssage_armed" ] || return 0
+  [ -n "$COMP_LINE" ] && return 0
+  __ssage_armed=
+  # Enter on an empty line fires the trap without adding to history: do
+  # not record the previous command again.
+  __ssage_histnum
+  [ "$__ssage_num" = "$__ssage_seen" ] && return 0
+  __ssage_seen=$__ssage_num
+  __ssage_cmd=${__ssage_hist#*[0-9][ *] }
+  __ssage_start=${EPOCHREALTIME:-$(date +%s)}
+  if [ "$__ssage_capture" = 1 ]; then
+    exec 8>"$__ssage_errfile"
+    exec 9>&2 2> >(tee -a /dev/fd/8 >&9)
+  fi
+}
+
+__ssage_precmd() {
+  local status=$?
+  if [ -n "$__ssage_cmd" ]; then
+    local errargs=()
+    if [ "$__ssage_capture" = 1 ]; then
+      exec 2>&9 9>&- 8>&-
+      errargs=(--stderr-file "$__ssage_errfile")
+    fi
+    "$__ssage_exe" _record --shell bash --exit "$status" \
+      --start "$__ssage_start" --end "${EPOCHREALTIME:-$(date +%s)}" \
+      --cwd "$PWD" "${errargs[@]}" -- "$__ssage_cmd" 2>/dev/null
+    __ssage_cmd=
+  fi
+  return $status
+}
+
+__ssage_arm() { __ssage_armed=1; }
+
+__ssage_histnum
+__ssage_seen=$__ssage_num
+
+trap '__ssage_preexec' DEBUG
+PROMPT_COMMAND="__ssage_precmd;${PROMPT_COMMAND:+$PROMPT_COMMAND;}__ssage_arm"
+`
+
+const zshHook = `# ssage shell integration for zsh — add to ~/.zshrc:
+#   eval "$(ssage init zsh)"
+# Records exit code, duration and cwd of each command for 'ssage fix'.
+zmodload zsh/datetime 2>/dev/null
+autoload -Uz add-zsh-hook
+typeset -g __ssage_exe={{EXE}}
+typeset -g __ssage_capture={{CAPTURE}}
+typeset -g __ssage_errfile=
+typeset -g __ssage_cmd=
+if [[ $__ssage_capture == 1 ]]; then
+  if __ssage_errfile=$(umask 077; mktemp "${XDG_RUNTIME_DIR:-${TMPDIR:-/tmp}}/ssage-stderr.XXXXXX"); then
+    __ssage_cleanup() { rm -f "$__ssage_errfile"; }
+    add-zsh-hook zshexit __ssage_cleanup
+  else
+    __ssage_capture=0
+  fi
+fi
+
+__ssage_preexec() {
+  __ssage_cmd=$1
+  __ssage_start=$EPOCHREALTIME
+  if [[ $__ssage_capture == 1 ]]; then
+    exec {__ssage_errfd}>"$__ssage_errfile"
+    exec {__ssage_fd}>&2 2> >(tee -a /dev/fd/$__ssage_errfd >&$__ssage_fd)
+  fi
+}
+
+__ssage_precmd() {
+  local exit_status=$?
+  [[ -n $__ssage_cmd ]] || return
+  local -a errargs
+  if [[ $__ssage_capture == 1 && -n $__ssage_fd ]]; then
+    exec 2>&$__ssage_fd {__ssage_fd}>&- {__ssage_errfd}>&-
+    errargs=(--stderr-file "$__ssage_errfile")
+  fi
+  "$__ssage_exe" _record --shell zsh --exit $exit_status \
+    --start $__ssage_start --end $EPOCHREALTIME \
+    --cwd "$PWD" $errargs -- "$__ssage_cmd" 2>/dev/null
+  __ssage_cmd=
+}
+
+add-zsh-hook preexec __ssage_preexec
+add-zsh-hook precmd __ssage_precmd
+`
+
+const fishHook = `# ssage shell integration for fish — add to ~/.config/fish/config.fish:
+#   ssage init fish | source
+# Records exit code, duration and cwd of each command for 'ssage fix'.
+set -g __ssage_exe {{EXE}}
+
+function __ssage_postexec --on-event fish_postexec
+    set -l exit_status $status
+    test -n "$argv[1]"; or return
+    $__ssage_exe _record --shell fish --exit $exit_status \
+        --duration-ms $CMD_DURATION --cwd "$PWD" -- "$argv[1]" 2>/dev/null
+end
+`
@@ -0,0 +1,197 @@
+// Package journal records the outcome of every interactive shell command
+// (exit status, duration, working directory and optionally stderr) so that
+// `ssage fix` can target the command that actually failed instead of
+// guessing from plain history.
+//
+// Entries are appended as JSON lines to ~/.ssage_journal.jsonl by the hidden
+// `ssage _record` command, which the snippets printed by `ssage init <shell>`
+// invoke after each command. The file is trimmed to the most recent entries
+// once it grows past a size threshold.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// MaxStderrBytes bounds the captured stderr kept per entry (the tail is kept).
+	MaxStderrBytes = 4 << 10
+
+	// trimThreshold is the file size past which Append rewrites the journal
+	// keeping only the newest keepEntries entries.
+	trimThreshold = 2 << 20
+	keepEntries   = 2000
+)
+
+// Entry is one executed shell command.
+type Entry struct {
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exit_code"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration_ns"`
+	Cwd      string        `json:"cwd,omitempty"`
+	Shell    string        `json:"shell,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+}
+
+// Failed reports whether the command exited with a non-zero status. Exit
+// code 130 (interrupted with Ctrl-C) is not considered a failure.
+func (e Entry) Failed() bool {
+	return e.ExitCode != 0 && e.ExitCode != 130
+}
+
+// Path returns the journal file path. SSAGE_JOURNAL overrides the default
+// ~/.ssage_journal.jsonl.
+func Path() string {
+	if p := os.Getenv("SSAGE_JOURNAL"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), ".ssage_journal.jsonl")
+	}
+	return filepath.Join(home, ".ssage_journal.jsonl")
+}
+
+// Installed reports whether a journal exists, i.e. the shell hook has
+// recorded at least one command.
+func Installed() bool {
+	_, err := os.Stat(Path())
+	return err == nil
+}
+
+// Append adds e to the journal. Stderr is truncated to its last
+// MaxStderrBytes bytes.
+func Append(e Entry) error {
+	if len(e.Stderr) > MaxStderrBytes {
+		e.Stderr = e.Stderr[len(e.Stderr)-MaxStderrBytes:]
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	path := Path()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open journal %s: %w", path, err)
+	}
+	// A single write per entry keeps concurrent shells from interleaving lines.
+	_, err = f.Write(append(line, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > trimThreshold {
+		_ = trim(path)
+	}
+	return nil
+}
+
+// All returns every entry in the journal, oldest first. A missing journal
+// yields no entries and no error.
+func All() ([]Entry, error) {
+	return readAll(Path())
+}
+
+// Recent returns up to n of the most recent entries, oldest first.
+// A missing journal yields no entries and no error.
+func Recent(n int) ([]Entry, error) {
+	entries, err := readAll(Path())
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
+
+// LastFailure returns the newest entry for which skip (if non-nil) reports
+// false, provided that command failed, or nil. A failure followed by a
+// successful command is not returned: the user has moved on from it. skip
+// lets callers pass over ssage's own invocations.
+func LastFailure(skip func(Entry) bool) (*Entry, error) {
+	entries, err := All()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if skip != nil && skip(entries[i]) {
+			continue
+		}
+		if entries[i].Failed() {
+			return &entries[i], nil
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+// readAll parses every entry in the journal, skipping malformed lines.
+func readAll(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not open journal %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var e Entry
+			if json.Unmarshal(line, &e) == nil {
+				entries = append(entries, e)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// trim rewrites the journal keeping only the newest keepEntries entries.
+func trim(path string) error {
+	entries, err := readAll(path)
+	if err != nil || len(entries) <= keepEntries {
+		return err
+	}
+	entries = entries[len(entries)-keepEntries:]
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ssage_journal-*")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
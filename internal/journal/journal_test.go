@@ -0,0 +1,152 @@
+package journal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastFailure(t *testing.T) {
+	t.Setenv("SSAGE_JOURNAL", filepath.Join(t.TempDir(), "journal.jsonl"))
+	self := func(e Entry) bool { return strings.HasPrefix(e.Command, "ssage ") }
+	add := func(entries ...Entry) {
+		t.Helper()
+		for _, e := range entries {
+			if err := Append(e); err != nil {
+				t.Fatalf("Append: %v", err)
+			}
+		}
+	}
+
+	if e, err := LastFailure(self); err != nil || e != nil {
+		t.Fatalf("LastFailure on missing journal = %v, %v; want nil, nil", e, err)
+	}
+
+	now := time.Now()
+	add(
+		Entry{Command: "make build", ExitCode: 2, Start: now, Stderr: "missing target"},
+		Entry{Command: "ssage explain make", ExitCode: 0, Start: now},
+	)
+	e, err := LastFailure(self)
+	if err != nil {
+		t.Fatalf("LastFailure: %v", err)
+	}
+	if e == nil || e.Command != "make build" || e.Stderr != "missing target" {
+		t.Errorf("LastFailure = %+v, want the 'make build' entry", e)
+	}
+	if e, _ := LastFailure(nil); e != nil {
+		t.Errorf("LastFailure(nil) = %+v, want nil after a successful ssage run", e)
+	}
+
+	// A later command, even one that succeeded, supersedes the failure.
+	add(Entry{Command: "ls", ExitCode: 0, Start: now})
+	if e, _ := LastFailure(self); e != nil {
+		t.Errorf("LastFailure = %+v, want nil after a successful command", e)
+	}
+
+	// An interrupted command is not a failure.
+	add(Entry{Command: "make build", ExitCode: 2, Start: now}, Entry{Command: "sleep 100", ExitCode: 130, Start: now})
+	if e, _ := LastFailure(self); e != nil {
+		t.Errorf("LastFailure = %+v, want nil after an interrupted command", e)
+	}
+}
+
+func TestAppend_TruncatesStderr(t *testing.T) {
+	t.Setenv("SSAGE_JOURNAL", filepath.Join(t.TempDir(), "journal.jsonl"))
+
+	stderr := strings.Repeat("a", MaxStderrBytes) + "tail"
+	if err := Append(Entry{Command: "x", ExitCode: 1, Stderr: stderr}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	entries, err := Recent(1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Recent = %v, %v", entries, err)
+	}
+	got := entries[0].Stderr
+	if len(got) != MaxStderrBytes || !strings.HasSuffix(got, "tail") {
+		t.Errorf("stderr not tail-truncated: len=%d", len(got))
+	}
+}
+
+func TestHook(t *testing.T) {
+	for _, shell := range Shells {
+		out, err := Hook(shell, "/opt/my bin/ssage", false)
+		if err != nil {
+			t.Fatalf("Hook(%s): %v", shell, err)
+		}
+		if !strings.Contains(out, "'/opt/my bin/ssage'") {
+			t.Errorf("Hook(%s) does not quote the executable path:\n%s", shell, out)
+		}
+	}
+	if _, err := Hook("tcsh", "ssage", false); err == nil {
+		t.Error("Hook(tcsh) should fail")
+	}
+}
+
+// TestHook_CaptureStderr verifies that the stderr file is created with
+// mktemp rather than at a guessable path, and removed on exit.
+func TestHook_CaptureStderr(t *testing.T) {
+	for shell, cleanup := range map[string]string{"bash": "trap 'rm -f", "zsh": "add-zsh-hook zshexit"} {
+		out, err := Hook(shell, "ssage", true)
+		if err != nil {
+			t.Fatalf("Hook(%s): %v", shell, err)
+		}
+		if !strings.Contains(out, "mktemp") || strings.Contains(out, "$$") {
+			t.Errorf("Hook(%s) does not create the stderr file with mktemp:\n%s", shell, out)
+		}
+		if !strings.Contains(out, cleanup) {
+			t.Errorf("Hook(%s) does not remove the stderr file on exit:\n%s", shell, out)
+		}
+	}
+	if _, err := Hook("fish", "ssage", true); err == nil {
+		t.Error("Hook(fish) with stderr capture should fail")
+	}
+}
+
+// TestHook_BashEmptyEnter runs the bash hook in an interactive shell and
+// verifies that pressing Enter on an empty prompt does not record the
+// previous command again.
+func TestHook_BashEmptyEnter(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	for _, capture := range []bool{false, true} {
+		t.Run(fmt.Sprintf("capture=%v", capture), func(t *testing.T) {
+			dir := t.TempDir()
+			log := filepath.Join(dir, "recorded")
+			recorder := filepath.Join(dir, "record.sh")
+			// Log the recorded command, the last argument.
+			script := fmt.Sprintf("#!/bin/sh\nfor a; do last=$a; done\nprintf '%%s\\n' \"$last\" >> '%s'\n", log)
+			if err := os.WriteFile(recorder, []byte(script), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			hook, err := Hook("bash", recorder, capture)
+			if err != nil {
+				t.Fatalf("Hook: %v", err)
+			}
+			hookFile := filepath.Join(dir, "hook.bash")
+			if err := os.WriteFile(hookFile, []byte(hook), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			c := exec.Command(bash, "--norc", "--noprofile", "-i")
+			c.Env = append(os.Environ(), "HOME="+dir, "HISTFILE="+filepath.Join(dir, "history"), "XDG_RUNTIME_DIR="+dir, "PROMPT_COMMAND=")
+			c.Stdin = strings.NewReader("source '" + hookFile + "'\nfalse\n\n\ntrue\n\nexit\n")
+			if out, err := c.CombinedOutput(); err != nil {
+				t.Fatalf("bash: %v\n%s", err, out)
+			}
+			got, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatalf("nothing recorded: %v", err)
+			}
+			if want := "false\ntrue\n"; string(got) != want {
+				t.Errorf("recorded %q, want %q", got, want)
+			}
+		})
+	}
+}
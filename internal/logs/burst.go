@@ -0,0 +1,125 @@
+package logs
+
+import (
+	"regexp"
+	"time"
+)
+
+// errorRe matches lines logged at error level or above, and the first line
+// of a stack trace. Unlike Extract, warnings do not count towards a burst.
+var errorRe = regexp.MustCompile(`(?i)\b(error|err|fatal|panic|crit|critical|severe|alert|emerg|exception|failed|failure)\b|^Traceback \(most recent call last\):`)
+
+// IsError reports whether line is logged at error level or above.
+func IsError(line string) bool { return errorRe.MatchString(line) }
+
+// Incident is a burst of error lines found by a Detector.
+type Incident struct {
+	// Lines holds the burst together with the lines logged shortly before
+	// it, in order.
+	Lines []string
+
+	// Errors counts the error lines in Lines.
+	Errors int
+
+	// Start and End are when the first and last error line arrived.
+	Start, End time.Time
+}
+
+// Detector watches a stream of log lines for error bursts: at least
+// Threshold error lines within Window. A burst lasts until no error line
+// has arrived for Quiet, or until it holds MaxLines lines.
+type Detector struct {
+	Threshold int
+	Window    time.Duration
+	Quiet     time.Duration
+	MaxLines  int
+
+	recent []stampedLine
+	burst  *Incident
+}
+
+type stampedLine struct {
+	at    time.Time
+	text  string
+	error bool
+}
+
+// NewDetector returns a Detector reporting threshold error lines within
+// window, with a quiet period of a fifth of the window (at least two
+// seconds) and bursts of at most 200 lines.
+func NewDetector(threshold int, window time.Duration) *Detector {
+	return &Detector{
+		Threshold: threshold,
+		Window:    window,
+		Quiet:     max(window/5, 2*time.Second),
+		MaxLines:  200,
+	}
+}
+
+// Add records a line that arrived at now. It returns the current burst if
+// the line filled it up to MaxLines, otherwise nil.
+func (d *Detector) Add(now time.Time, line string) *Incident {
+	isErr := IsError(line)
+	if b := d.burst; b != nil {
+		b.Lines = append(b.Lines, line)
+		if isErr {
+			b.Errors++
+			b.End = now
+		}
+		if len(b.Lines) >= d.MaxLines {
+			return d.finish()
+		}
+		return nil
+	}
+
+	d.recent = append(d.recent, stampedLine{at: now, text: line, error: isErr})
+	cutoff := now.Add(-d.Window)
+	for len(d.recent) > 0 && (d.recent[0].at.Before(cutoff) || len(d.recent) > d.MaxLines) {
+		d.recent = d.recent[1:]
+	}
+	if !isErr {
+		return nil
+	}
+
+	count := 0
+	for _, l := range d.recent {
+		if l.error {
+			count++
+		}
+	}
+	if count < d.Threshold {
+		return nil
+	}
+	b := &Incident{Errors: count, End: now}
+	for _, l := range d.recent {
+		if l.error && b.Start.IsZero() {
+			b.Start = l.at
+		}
+		b.Lines = append(b.Lines, l.text)
+	}
+	d.burst, d.recent = b, nil
+	return nil
+}
+
+// Tick returns the current burst once no error line has arrived for
+// Quiet, otherwise nil. Call it periodically.
+func (d *Detector) Tick(now time.Time) *Incident {
+	if d.burst != nil && now.Sub(d.burst.End) >= d.Quiet {
+		return d.finish()
+	}
+	return nil
+}
+
+// Flush returns the current burst, if any, e.g. when the input ends.
+func (d *Detector) Flush() *Incident {
+	if d.burst == nil {
+		return nil
+	}
+	return d.finish()
+}
+
+func (d *Detector) finish() *Incident {
+	b := d.burst
+	d.burst = nil
+	return b
+}
@@ -0,0 +1,63 @@
+package logs
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDetector verifies that a burst needs Threshold errors within Window,
+// keeps the lines before it and ends after Quiet.
+func TestDetector(t *testing.T) {
+	d := NewDetector(3, 10*time.Second)
+	t0 := time.Unix(1700000000, 0)
+	at := func(sec int) time.Time { return t0.Add(time.Duration(sec) * time.Second) }
+
+	// Two errors 20s apart never make a burst.
+	d.Add(at(0), "ERROR one")
+	d.Add(at(20), "ERROR two")
+	d.Add(at(21), "INFO retrying")
+	if d.Tick(at(40)) != nil {
+		t.Fatal("unexpected burst from sparse errors")
+	}
+
+	d.Add(at(41), "INFO request")
+	d.Add(at(42), "ERROR db timeout")
+	if inc := d.Add(at(43), "ERROR db timeout"); inc != nil {
+		t.Fatal("burst returned before it ended")
+	}
+	d.Add(at(44), "INFO still serving")
+	d.Add(at(45), "ERROR db timeout")
+	if d.Tick(at(46)) != nil {
+		t.Fatal("burst ended before the quiet period")
+	}
+	inc := d.Tick(at(45).Add(d.Quiet))
+	if inc == nil {
+		t.Fatal("expected a burst after the quiet period")
+	}
+	// Lines older than the window when the burst starts are left out.
+	want := []string{"INFO request", "ERROR db timeout", "ERROR db timeout", "INFO still serving", "ERROR db timeout"}
+	if inc.Errors != 3 || len(inc.Lines) != len(want) || !inc.Start.Equal(at(42)) || !inc.End.Equal(at(45)) {
+		t.Fatalf("unexpected incident: %+v", inc)
+	}
+	for i := range want {
+		if inc.Lines[i] != want[i] {
+			t.Errorf("Lines[%d] = %q, want %q", i, inc.Lines[i], want[i])
+		}
+	}
+	if d.Flush() != nil {
+		t.Error("the burst should have been reset")
+	}
+}
+
+func TestDetector_MaxLines(t *testing.T) {
+	d := NewDetector(2, time.Minute)
+	d.MaxLines = 5
+	now := time.Unix(1700000000, 0)
+	var inc *Incident
+	for i := 0; i < 10 && inc == nil; i++ {
+		inc = d.Add(now, "panic: boom")
+	}
+	if inc == nil || len(inc.Lines) != 5 {
+		t.Fatalf("expected a full burst of 5 lines, got %+v", inc)
+	}
+}
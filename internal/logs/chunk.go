@@ -0,0 +1,89 @@
+// Package logs prepares log files for analysis by a model whose context
+// window is much smaller than a typical log: it reads (and decompresses)
+// them, keeps the lines worth analyzing, splits the rest into windows that
+// fit the context and runs the per-window requests concurrently.
+package logs
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultNumCtx is the context length assumed when none is configured.
+	// It is Ollama's default, and small enough for any hosted model.
+	DefaultNumCtx = 2048
+
+	// charsPerToken is a conservative estimate for log text, which has more
+	// numbers and punctuation (and so more tokens per character) than prose.
+	charsPerToken = 3
+
+	// minChunk keeps chunks useful even with a tiny configured context.
+	minChunk = 1000
+)
+
+// Chunk is a window of consecutive log lines.
+type Chunk struct {
+	Text string
+
+	// Source names the log the chunk was cut from when several logs are
+	// analyzed together (see SplitSources).
+	Source string
+
+	// FirstLine and LastLine are the 1-based line numbers the chunk spans.
+	FirstLine, LastLine int
+}
+
+// ChunkSize returns how many characters of log fit in one request to a
+// model with a context window of numCtx tokens (DefaultNumCtx if zero). A
+// quarter of the window, and at least 512 tokens, is left for the
+// instructions and the answer.
+func ChunkSize(numCtx int) int {
+	if numCtx <= 0 {
+		numCtx = DefaultNumCtx
+	}
+	reserve := numCtx / 4
+	if reserve < 512 {
+		reserve = 512
+	}
+	return max((numCtx-reserve)*charsPerToken, minChunk)
+}
+
+// Split cuts text into chunks of at most size characters, breaking only
+// between lines. A line longer than size is cut into several chunks.
+func Split(text string, size int) []Chunk {
+	var (
+		chunks []Chunk
+		b      strings.Builder
+		first  = 1
+		line   = 0
+	)
+	flush := func(last int) {
+		if b.Len() > 0 {
+			chunks = append(chunks, Chunk{Text: b.String(), FirstLine: first, LastLine: last})
+			b.Reset()
+		}
+		first = last + 1
+	}
+
+	for len(text) > 0 {
+		line++
+		l, rest, _ := strings.Cut(text, "\n")
+		text = rest
+		if b.Len() > 0 && b.Len()+len(l)+1 > size {
+			flush(line - 1)
+		}
+		for len(l) > size {
+			cut := size
+			for cut > 1 && !utf8.RuneStart(l[cut]) {
+				cut--
+			}
+			chunks = append(chunks, Chunk{Text: l[:cut], FirstLine: line, LastLine: line})
+			l = l[cut:]
+		}
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	flush(line)
+	return chunks
+}
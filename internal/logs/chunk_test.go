@@ -0,0 +1,67 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestChunkSize(t *testing.T) {
+	if got := ChunkSize(0); got != ChunkSize(DefaultNumCtx) {
+		t.Errorf("ChunkSize(0) = %d, want the default context's %d", got, ChunkSize(DefaultNumCtx))
+	}
+	if got := ChunkSize(8192); got != 6144*charsPerToken {
+		t.Errorf("ChunkSize(8192) = %d, want %d", got, 6144*charsPerToken)
+	}
+	if got := ChunkSize(100); got != minChunk {
+		t.Errorf("ChunkSize(100) = %d, want the minimum %d", got, minChunk)
+	}
+}
+
+// TestSplit verifies that chunks respect the size, break between lines,
+// track line numbers and together reproduce the input.
+func TestSplit(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&b, "line %d: %s\n", i, strings.Repeat("x", i%13))
+	}
+	text := b.String()
+
+	chunks := Split(text, 200)
+	var joined strings.Builder
+	next := 1
+	for _, c := range chunks {
+		if len(c.Text) > 200 {
+			t.Errorf("chunk of %d chars exceeds the size", len(c.Text))
+		}
+		if c.FirstLine != next || !strings.HasPrefix(c.Text, fmt.Sprintf("line %d:", c.FirstLine)) {
+			t.Errorf("chunk %d-%d starts with %q, want line %d", c.FirstLine, c.LastLine, c.Text[:10], next)
+		}
+		if n := strings.Count(c.Text, "\n"); n != c.LastLine-c.FirstLine+1 {
+			t.Errorf("chunk %d-%d holds %d lines", c.FirstLine, c.LastLine, n)
+		}
+		next = c.LastLine + 1
+		joined.WriteString(c.Text)
+	}
+	if joined.String() != text {
+		t.Error("chunks do not reproduce the input")
+	}
+}
+
+// TestSplit_LongLine verifies that an oversized line is cut without
+// splitting a UTF-8 character.
+func TestSplit_LongLine(t *testing.T) {
+	long := strings.Repeat("é", 150) // 300 bytes
+	chunks := Split("before\n"+long+"\nafter", 100)
+	if len(chunks) != 5 {
+		t.Fatalf("got %d chunks, want 5: %+v", len(chunks), chunks)
+	}
+	if chunks[0].Text != "before\n" || chunks[4].Text != "after\n" {
+		t.Errorf("unexpected first/last chunk: %q %q", chunks[0].Text, chunks[4].Text)
+	}
+	for _, c := range chunks[1:4] {
+		if c.FirstLine != 2 || !strings.HasPrefix(c.Text, "é") {
+			t.Errorf("bad piece of the long line: %+v", c)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultContext is the number of lines kept before and after each
+// interesting line when none is configured.
+const DefaultContext = 2
+
+// maxBlock bounds how many lines of a single stack trace are kept.
+const maxBlock = 60
+
+var (
+	// levelRe matches lines logged at warning level or above, in plain text
+	// as well as logfmt (level=error) and JSON ("level":"error") lines.
+	levelRe = regexp.MustCompile(`(?i)\b(error|err|warn|warning|fatal|panic|crit|critical|severe|alert|emerg|exception|failed|failure)\b`)
+
+	// traceStartRe matches the first line of a multi-line stack trace.
+	traceStartRe = regexp.MustCompile(`^(panic: |fatal error: |goroutine \d+ \[|Traceback \(most recent call last\):|Exception in thread |Caused by: |[\w.$]+(Exception|Error)(: |$))`)
+
+	// traceLineRe matches the lines inside a stack trace: indented frames,
+	// Java "at"/"Caused by:"/"... N more" lines, Go "goroutine" headers and
+	// the "pkg.func(...)" lines that precede Go file:line frames.
+	traceLineRe = regexp.MustCompile(`^(\s+\S|Caused by: |\.\.\. \d+ more|goroutine \d+ \[|created by |[\w./*()\[\]-]+\(.*\)$)`)
+)
+
+// Excerpt is the interesting part of a log selected by Extract.
+type Excerpt struct {
+	// Text holds the kept lines grouped by issue, each group headed by the
+	// line it was first seen on and how often it occurred.
+	Text string
+
+	// TotalLines and KeptLines count the lines of the log and the distinct
+	// lines kept in Text (context included).
+	TotalLines, KeptLines int
+
+	// Issues counts the distinct issues, Occurrences all of their matches.
+	Issues, Occurrences int
+}
+
+// Dropped returns the number of log lines left out of the excerpt.
+func (e Excerpt) Dropped() int { return e.TotalLines - e.KeptLines }
+
+// issue is one distinct problem: the first occurrence's lines and where it
+// recurred.
+type issue struct {
+	first, last int // 1-based lines of the first and last occurrence
+	count       int
+	lines       []string
+	start       int // 1-based line of lines[0]
+}
+
+// Extract selects the lines of text worth analyzing: lines logged at error
+// or warning level and whole stack traces (Go panics and goroutine dumps,
+// Python tracebacks, Java exceptions with their "Caused by:" chains), each
+// with context lines around it. Occurrences of the same issue that differ
+// only in numbers, IDs and timestamps are kept once with a count.
+func Extract(text string, context int) Excerpt {
+	if context < 0 {
+		context = 0
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if text == "" {
+		lines = nil
+	}
+
+	var (
+		issues []*issue
+		byKey  = make(map[string]*issue)
+	)
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		trace := traceStartRe.MatchString(l)
+		if !trace && !levelRe.MatchString(l) {
+			continue
+		}
+		end := i + 1 // the block is lines[i:end]
+		if trace || startsTrace(lines, i+1) {
+			end = traceEnd(lines, i+1)
+		}
+
+		key := normalize(strings.Join(lines[i:end], "\n"))
+		if is, ok := byKey[key]; ok {
+			is.count++
+			is.last = i + 1
+		} else {
+			from := max(0, i-context)
+			to := min(len(lines), end+context)
+			is := &issue{first: i + 1, last: i + 1, count: 1, lines: lines[from:to], start: from + 1}
+			byKey[key] = is
+			issues = append(issues, is)
+		}
+		i = end - 1
+	}
+
+	ex := Excerpt{TotalLines: len(lines), Issues: len(issues)}
+	kept := make(map[int]bool)
+	var b strings.Builder
+	for _, is := range issues {
+		ex.Occurrences += is.count
+		fmt.Fprintf(&b, "--- line %d", is.first)
+		if is.count > 1 {
+			fmt.Fprintf(&b, " (×%d, last at line %d)", is.count, is.last)
+		}
+		b.WriteString(" ---\n")
+		for j, l := range is.lines {
+			kept[is.start+j] = true
+			b.WriteString(l + "\n")
+		}
+	}
+	ex.KeptLines = len(kept)
+	ex.Text = b.String()
+	return ex
+}
+
+// startsTrace reports whether the line at i continues a stack trace started
+// by the line before it, e.g. "\tat com.example.Main.run(Main.java:12)"
+// after an exception message.
+func startsTrace(lines []string, i int) bool {
+	return i < len(lines) && (traceLineRe.MatchString(lines[i]) || traceStartRe.MatchString(lines[i]))
+}
+
+// traceEnd returns the index just past the stack trace continuing at i. A
+// trace runs over frame lines and blank lines between Go goroutine dumps;
+// a Python traceback also takes the unindented exception line ending it.
+func traceEnd(lines []string, i int) int {
+	start := i
+	for i < len(lines) && i-start < maxBlock {
+		l := lines[i]
+		switch {
+		case traceLineRe.MatchString(l), traceStartRe.MatchString(l):
+		case l == "" && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "goroutine "):
+		case i > 0 && strings.HasPrefix(lines[i-1], "  ") && pythonExceptionRe.MatchString(l):
+			return i + 1
+		default:
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// pythonExceptionRe matches the "ValueError: message" line closing a
+// Python traceback.
+var pythonExceptionRe = regexp.MustCompile(`^[\w.]+(Error|Exception|Exit|Interrupt|Warning)\b`)
+
+// maskRules replace the variable parts of a log line, most specific first,
+// so repeated occurrences of the same issue normalize to the same key.
+var maskRules = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`), "<ts>"},
+	{regexp.MustCompile(`\b(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) +\d{1,2} \d{2}:\d{2}:\d{2}\b`), "<ts>"},
+	{regexp.MustCompile(`\d{2}:\d{2}:\d{2}(?:[.,]\d+)?`), "<ts>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`), "<ip>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<hex>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]*\d[0-9a-f]*[a-f][0-9a-f]*\b|\b[0-9a-f]*[a-f][0-9a-f]*\d[0-9a-f]*\b`), "<id>"},
+	{regexp.MustCompile(`\d+`), "<n>"},
+}
+
+// normalize masks timestamps, IDs, addresses and numbers in s.
+func normalize(s string) string {
+	for _, r := range maskRules {
+		s = r.re.ReplaceAllString(s, r.repl)
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestExtract_Dedup verifies that repeated errors differing only in
+// timestamps, IDs and numbers are kept once with a count.
+func TestExtract_Dedup(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&b, "2024-05-01T10:00:%02dZ INFO served request %d in %dms\n", i%60, i, i*3)
+		if i%10 == 5 {
+			fmt.Fprintf(&b, "2024-05-01T10:00:%02dZ ERROR upstream 10.0.0.%d:8080 timed out after %dms (req=%x)\n", i%60, i, i*100, 0xabc000+i)
+		}
+	}
+	b.WriteString("2024-05-01T10:02:00Z WARN disk usage at 91%\n")
+
+	ex := Extract(b.String(), 0)
+	if ex.Issues != 2 || ex.Occurrences != 11 {
+		t.Fatalf("Issues=%d Occurrences=%d, want 2 and 11\n%s", ex.Issues, ex.Occurrences, ex.Text)
+	}
+	if !strings.Contains(ex.Text, "--- line 7 (×10, last at line 106) ---\n") {
+		t.Errorf("missing the count header:\n%s", ex.Text)
+	}
+	if ex.TotalLines != 111 || ex.KeptLines != 2 || ex.Dropped() != 109 {
+		t.Errorf("TotalLines=%d KeptLines=%d, want 111 and 2", ex.TotalLines, ex.KeptLines)
+	}
+}
+
+// TestExtract_Context verifies surrounding lines are kept.
+func TestExtract_Context(t *testing.T) {
+	ex := Extract("a\nb\nc\nERROR boom\nd\ne\nf\n", 2)
+	if ex.Text != "--- line 4 ---\nb\nc\nERROR boom\nd\ne\n" {
+		t.Errorf("unexpected excerpt:\n%s", ex.Text)
+	}
+}
+
+// TestExtract_Traces verifies that whole stack traces are kept as one issue.
+func TestExtract_Traces(t *testing.T) {
+	tests := []struct {
+		name, log, want string
+	}{
+		{
+			name: "go panic",
+			log: "starting\npanic: runtime error: index out of range [3] with length 2\n\ngoroutine 1 [running]:\n" +
+				"main.lookup(...)\n\t/src/main.go:12\nmain.main()\n\t/src/main.go:20 +0x1d\n\ngoroutine 7 [chan receive]:\n" +
+				"main.worker()\n\t/src/main.go:30 +0x25\nexit status 2\n",
+			want: "\t/src/main.go:30 +0x25\n",
+		},
+		{
+			name: "python traceback",
+			log: "INFO start\nTraceback (most recent call last):\n  File \"app.py\", line 3, in <module>\n    main()\n" +
+				"  File \"app.py\", line 2, in main\n    raise ValueError(\"bad\")\nValueError: bad\nINFO next\n",
+			want: "ValueError: bad\n",
+		},
+		{
+			name: "java caused by",
+			log: "INFO ok\nERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.A.run(A.java:10)\n" +
+				"\tat com.example.Main.main(Main.java:5)\nCaused by: java.io.IOException: closed\n\tat com.example.B.read(B.java:7)\n\t... 2 more\nINFO next\n",
+			want: "\t... 2 more\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := Extract(tt.log, 0)
+			if ex.Issues != 1 {
+				t.Fatalf("got %d issues, want 1:\n%s", ex.Issues, ex.Text)
+			}
+			if !strings.HasSuffix(ex.Text, tt.want) {
+				t.Errorf("trace not kept whole:\n%s", ex.Text)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	a := normalize("2024-05-01 10:00:01,123 conn 3f2a9c1e-0000-4000-8000-00000000abcd from 192.168.1.4:5432 ptr 0xc000123 took 15ms")
+	b := normalize("2024-06-11 22:14:59,999 conn 11111111-2222-4333-8444-555555555555 from 10.1.1.1:80 ptr 0xc000999 took 7ms")
+	if a != b {
+		t.Errorf("normalize differs:\n%s\n%s", a, b)
+	}
+}
@@ -0,0 +1,141 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// maxPartial bounds an unterminated line kept between reads; longer lines
+// are emitted in pieces.
+const maxPartial = 64 << 10
+
+// Tail follows the file at path like `tail -F`, calling emit for every line
+// appended after Tail starts. It polls every interval and copes with both
+// kinds of log rotation: when the file shrinks (copytruncate) it reads again
+// from the start, and when a new file replaces it (rename and create) it
+// finishes the old file and reads the new one from its beginning. Tail
+// returns nil when ctx is cancelled.
+func Tail(ctx context.Context, path string, interval time.Duration, emit func(line string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	var (
+		partial []byte
+		buf     = make([]byte, 32<<10)
+		ticker  = time.NewTicker(interval)
+	)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := f.Read(buf)
+			offset += int64(n)
+			partial = emitLines(append(partial, buf[:n]...), emit)
+			if err == io.EOF || n == 0 {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			// Truncated in place: everything now in the file is new.
+			if offset, err = f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			partial = nil
+			continue
+		}
+		if cur, err := os.Stat(path); err == nil && !os.SameFile(cur, info) {
+			// Rotated: the old file is drained, switch to the new one.
+			if len(partial) > 0 {
+				emit(string(partial))
+				partial = nil
+			}
+			next, err := os.Open(path)
+			if err == nil {
+				f.Close()
+				f, offset = next, 0
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// emitLines calls emit for each complete line in data and returns the
+// unterminated remainder.
+func emitLines(data []byte, emit func(string)) []byte {
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		emit(string(bytes.TrimSuffix(data[:i], []byte("\r"))))
+		data = data[i+1:]
+	}
+	if len(data) > maxPartial {
+		emit(string(data))
+		return nil
+	}
+	// Copy so the caller's read buffer can be reused.
+	return append([]byte(nil), data...)
+}
+
+// Stream calls emit for every line read from r until EOF, e.g. for
+// `docker compose logs -f | ssage analyze --follow`. It returns early with
+// nil when ctx is cancelled.
+func Stream(ctx context.Context, r io.Reader, emit func(line string)) error {
+	lines := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if len(line) > 0 {
+				select {
+				case lines <- string(bytes.TrimRight([]byte(line), "\r\n")):
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				errc <- err
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case line := <-lines:
+			emit(line)
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
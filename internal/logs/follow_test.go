@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collector gathers emitted lines for Tail and Stream.
+type collector struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (c *collector) emit(line string) {
+	c.mu.Lock()
+	c.lines = append(c.lines, line)
+	c.mu.Unlock()
+}
+
+// waitFor polls until the collected lines equal want.
+func (c *collector) waitFor(t *testing.T, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		c.mu.Lock()
+		got := strings.Join(c.lines, "|")
+		c.mu.Unlock()
+		if got == strings.Join(want, "|") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("lines = %q, want %q", got, strings.Join(want, "|"))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTail verifies following appends, partial lines, truncation and
+// rotation by rename.
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendFile(t, path, "old line\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var c collector
+	done := make(chan error, 1)
+	go func() { done <- Tail(ctx, path, 10*time.Millisecond, c.emit) }()
+	time.Sleep(50 * time.Millisecond) // let Tail seek to the end
+
+	appendFile(t, path, "one\ntw")
+	c.waitFor(t, "one")
+	appendFile(t, path, "o\r\n")
+	c.waitFor(t, "one", "two")
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "after truncate\n")
+	c.waitFor(t, "one", "two", "after truncate")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path+".1", "late write\n")
+	appendFile(t, path, "new file\n")
+	c.waitFor(t, "one", "two", "after truncate", "late write", "new file")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Tail returned %v", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	var c collector
+	err := Stream(context.Background(), strings.NewReader("a\r\nb\nno newline"), c.emit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.waitFor(t, "a", "b", "no newline")
+}
@@ -0,0 +1,166 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Stdin is the argument that reads a log from standard input.
+const Stdin = "-"
+
+// Source is one log read by Read.
+type Source struct {
+	// Name labels the log in prompts and output: its path, or "stdin".
+	Name string
+	Text string
+}
+
+// Read reads the logs named by args. An argument is a path, a glob pattern
+// (for shells that do not expand it) or "-" for stdin. Logs compressed with
+// gzip, bzip2 or zstd are decompressed transparently, detected from their
+// content so rotated files such as app.log.2.gz and piped archives both
+// work.
+func Read(args []string, stdin io.Reader) ([]Source, error) {
+	var sources []Source
+	for _, arg := range args {
+		if arg == Stdin {
+			text, err := decode(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("reading stdin: %w", err)
+			}
+			sources = append(sources, Source{Name: "stdin", Text: text})
+			continue
+		}
+
+		paths := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", arg)
+			}
+			paths = matches
+		}
+		for _, p := range paths {
+			text, err := readPath(p)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, Source{Name: p, Text: text})
+		}
+	}
+	return sources, nil
+}
+
+func readPath(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return "", fmt.Errorf("%s is a directory; pass the log files in it, e.g. %s", path, filepath.Join(path, "*.log"))
+	}
+	text, err := decode(f)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	return text, nil
+}
+
+// Magic numbers of the supported compression formats.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// decode reads r, decompressing it if it starts with a known magic number.
+func decode(r io.Reader) (string, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+
+	var (
+		out []byte
+		err error
+	)
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		var zr *gzip.Reader
+		if zr, err = gzip.NewReader(br); err == nil {
+			out, err = io.ReadAll(zr)
+		}
+	case bytes.HasPrefix(head, bzip2Magic):
+		out, err = io.ReadAll(bzip2.NewReader(br))
+	case bytes.HasPrefix(head, zstdMagic):
+		out, err = unzstd(br)
+	default:
+		out, err = io.ReadAll(br)
+	}
+	return string(out), err
+}
+
+// unzstd decompresses zstd data with the zstd command-line tool, which
+// keeps ssage free of a compression library used only for rotated logs.
+func unzstd(r io.Reader) ([]byte, error) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		return nil, fmt.Errorf("decompressing zstd requires the zstd command-line tool.\n" +
+			"  → Install zstd (e.g. apt install zstd, brew install zstd)\n" +
+			"  → Or decompress it first: zstd -dc app.log.zst | ssage analyze -")
+	}
+	var stderr bytes.Buffer
+	c := exec.Command("zstd", "-dc")
+	c.Stdin = r
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("zstd failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Join concatenates the sources into one log. When there is more than one,
+// each starts with a "==> name <==" header, as printed by tail, so the
+// model can tell which file a line came from.
+func Join(sources []Source) string {
+	if len(sources) == 1 {
+		return sources[0].Text
+	}
+	var b strings.Builder
+	for i, s := range sources {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "==> %s <==\n%s", s.Name, s.Text)
+		if !strings.HasSuffix(s.Text, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// SplitSources splits each source into chunks of at most size characters,
+// so that no chunk mixes lines from two logs. Chunks carry the source name
+// when there is more than one source.
+func SplitSources(sources []Source, size int) []Chunk {
+	var chunks []Chunk
+	for _, s := range sources {
+		for _, c := range Split(s.Text, size) {
+			if len(sources) > 1 {
+				c.Source = s.Name
+			}
+			chunks = append(chunks, c)
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,91 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bzip2Fixture is "WARN slow query\n" compressed with bzip2; the standard
+// library can only decompress the format.
+const bzip2Fixture = "425a6839314159265359f1e87abd0000035780001040002001108002" +
+	"04baa02000314c00014d0d3d431ea80e92d847a1aa8f8bb9229c284878f43d5e80"
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.log", []byte("ERROR plain\n"))
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("ERROR gzipped\n"))
+	zw.Close()
+	write("app.log.1.gz", gz.Bytes())
+	bz, _ := hex.DecodeString(bzip2Fixture)
+	write("app.log.2.bz2", bz)
+
+	sources, err := Read([]string{filepath.Join(dir, "app.log*"), Stdin}, strings.NewReader("ERROR piped\n"))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var got []string
+	for _, s := range sources {
+		got = append(got, filepath.Base(s.Name)+"="+s.Text)
+	}
+	want := "app.log=ERROR plain\n|app.log.1.gz=ERROR gzipped\n|app.log.2.bz2=WARN slow query\n|stdin=ERROR piped\n"
+	if strings.Join(got, "|") != want {
+		t.Errorf("Read = %q\nwant %q", strings.Join(got, "|"), want)
+	}
+
+	if _, err := Read([]string{filepath.Join(dir, "*.txt")}, nil); err == nil {
+		t.Error("expected an error for a glob matching nothing")
+	}
+	if _, err := Read([]string{dir}, nil); err == nil || !strings.Contains(err.Error(), "directory") {
+		t.Errorf("expected a directory error, got %v", err)
+	}
+}
+
+func TestRead_Zstd(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd not installed")
+	}
+	c := exec.Command("zstd", "-q", "-c")
+	c.Stdin = strings.NewReader("ERROR compressed\n")
+	data, err := c.Output()
+	if err != nil {
+		t.Fatalf("zstd: %v", err)
+	}
+	sources, err := Read([]string{Stdin}, bytes.NewReader(data))
+	if err != nil || sources[0].Text != "ERROR compressed\n" {
+		t.Errorf("Read = %+v, %v", sources, err)
+	}
+}
+
+// TestJoin_SplitSources verifies that several logs are labelled and never
+// share a chunk.
+func TestJoin_SplitSources(t *testing.T) {
+	sources := []Source{{Name: "a.log", Text: "one\ntwo\n"}, {Name: "b.log", Text: "three"}}
+	if got := Join(sources); got != "==> a.log <==\none\ntwo\n\n==> b.log <==\nthree\n" {
+		t.Errorf("Join = %q", got)
+	}
+	if got := Join(sources[:1]); got != "one\ntwo\n" {
+		t.Errorf("Join of one source = %q", got)
+	}
+
+	chunks := SplitSources(sources, 1000)
+	if len(chunks) != 2 || chunks[0].Source != "a.log" || chunks[1].Source != "b.log" || chunks[1].FirstLine != 1 {
+		t.Errorf("SplitSources = %+v", chunks)
+	}
+	if chunks := SplitSources(sources[:1], 1000); chunks[0].Source != "" {
+		t.Errorf("a single source should not be labelled: %+v", chunks)
+	}
+}
@@ -0,0 +1,71 @@
+package logs
+
+import (
+	"context"
+	"sync"
+)
+
+// MapFunc analyzes chunk i and returns its findings.
+type MapFunc func(ctx context.Context, i int, c Chunk) (string, error)
+
+// Map calls fn for every chunk using at most workers goroutines and returns
+// the results in chunk order. done, if not nil, is called after each chunk
+// finishes, from one goroutine at a time. After the first error no further
+// chunks are started and that error is returned; cancelling ctx stops Map
+// the same way.
+func Map(ctx context.Context, chunks []Chunk, workers int, fn MapFunc, done func(i int, result string)) ([]string, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		results  = make([]string, len(chunks))
+		next     = make(chan int)
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < min(workers, len(chunks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				out, err := fn(ctx, i, chunks[i])
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					results[i] = out
+					if done != nil {
+						done(i, out)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range chunks {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
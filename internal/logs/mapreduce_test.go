@@ -0,0 +1,144 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/pipeline/middleware/redact"
+	"github.com/shell-sage/internal/provider"
+)
+
+func chunks(n int) []Chunk {
+	out := make([]Chunk, n)
+	for i := range out {
+		out[i] = Chunk{Text: fmt.Sprint(i), FirstLine: i + 1, LastLine: i + 1}
+	}
+	return out
+}
+
+// TestMap verifies results keep chunk order and concurrency stays bounded.
+func TestMap(t *testing.T) {
+	var running, peak atomic.Int32
+	var finished int
+	results, err := Map(context.Background(), chunks(20), 3, func(ctx context.Context, i int, c Chunk) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * time.Duration(20-i))
+		return "r" + c.Text, nil
+	}, func(i int, result string) { finished++ })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, r := range results {
+		if r != fmt.Sprintf("r%d", i) {
+			t.Fatalf("results[%d] = %q", i, r)
+		}
+	}
+	if peak.Load() > 3 {
+		t.Errorf("%d chunks ran at once, want at most 3", peak.Load())
+	}
+	if finished != 20 {
+		t.Errorf("done called %d times, want 20", finished)
+	}
+}
+
+// TestMap_StopsOnError verifies that the first error is returned and no new
+// chunks start after it.
+func TestMap_StopsOnError(t *testing.T) {
+	boom := errors.New("boom")
+	var started atomic.Int32
+	_, err := Map(context.Background(), chunks(100), 2, func(ctx context.Context, i int, c Chunk) (string, error) {
+		started.Add(1)
+		if i == 3 {
+			return "", boom
+		}
+		return "", nil
+	}, nil)
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if n := started.Load(); n > 10 {
+		t.Errorf("%d chunks started after the error", n)
+	}
+}
+
+func TestMap_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := Map(ctx, chunks(10), 1, func(ctx context.Context, i int, c Chunk) (string, error) {
+		cancel()
+		return "", nil
+	}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+// echoProvider answers every request with its last message.
+type echoProvider struct{}
+
+func (echoProvider) Chat(ctx context.Context, req provider.ChatRequest) (string, error) {
+	return req.Messages[len(req.Messages)-1].Content, nil
+}
+
+func (p echoProvider) ChatStream(ctx context.Context, req provider.ChatRequest, onChunk func(string)) (string, error) {
+	out, err := p.Chat(ctx, req)
+	onChunk(out)
+	return out, err
+}
+
+func (p echoProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	return p.Chat(ctx, provider.Prompt(prompt))
+}
+
+func (p echoProvider) GenerateStream(ctx context.Context, prompt string, onChunk func(string)) (string, error) {
+	return p.ChatStream(ctx, provider.Prompt(prompt), onChunk)
+}
+
+func (echoProvider) Name() string      { return "echo" }
+func (echoProvider) ModelName() string { return "echo" }
+
+// TestMap_PipelineMeta runs chunks through a redacting pipeline in parallel
+// the way analyze does: each chunk request gets its own Meta and the counts
+// are merged in done. Run with -race to catch a shared Meta.
+func TestMap_PipelineMeta(t *testing.T) {
+	m, err := redact.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pipe := pipeline.New(echoProvider{}, m)
+	in := make([]Chunk, 16)
+	for i := range in {
+		in[i] = Chunk{Text: fmt.Sprintf("ERROR login failed: mysql --password=s3cr3t%d -u root", i)}
+	}
+
+	var total pipeline.Meta
+	metas := make([]pipeline.Meta, len(in))
+	results, err := Map(context.Background(), in, 4, func(ctx context.Context, i int, c Chunk) (string, error) {
+		return pipe.Run(ctx, pipeline.Request{Prompt: c.Text, Meta: &metas[i]})
+	}, func(i int, result string) {
+		total.Redacted += metas[i].Redacted
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, r := range results {
+		if strings.Contains(r, "s3cr3t") {
+			t.Errorf("results[%d] leaked the secret: %q", i, r)
+		}
+	}
+	if total.Redacted != len(in) {
+		t.Errorf("Redacted = %d, want %d", total.Redacted, len(in))
+	}
+}
@@ -0,0 +1,563 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the line format of a log.
+type Format int
+
+const (
+	// Plain is free-form text, or any format not recognized below.
+	Plain Format = iota
+	// JSONLines holds one JSON object per line, as written by zap, zerolog,
+	// logrus, pino, bunyan, structlog and most other structured loggers.
+	JSONLines
+	// Logfmt holds key=value pairs per line, as written by logrus' text
+	// formatter, go-kit, slog's TextHandler and Heroku.
+	Logfmt
+	// Journal is a `journalctl -o json` export.
+	Journal
+	// AccessLog is the Common or Combined Log Format of nginx and Apache.
+	AccessLog
+)
+
+// String returns a human-readable name of the format.
+func (f Format) String() string {
+	switch f {
+	case JSONLines:
+		return "JSON lines"
+	case Logfmt:
+		return "logfmt"
+	case Journal:
+		return "journald JSON"
+	case AccessLog:
+		return "access log"
+	}
+	return "plain text"
+}
+
+// detectSample is how many non-empty lines DetectFormat looks at.
+const detectSample = 50
+
+// DetectFormat guesses the format of text from its first lines. A format
+// wins when most of the sampled lines parse as it, so a few plain lines
+// (a banner, a stack trace printed raw) do not hide it.
+func DetectFormat(text string) Format {
+	counts := make(map[Format]int)
+	sampled := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if sampled == detectSample {
+			break
+		}
+		sampled++
+		switch {
+		case strings.HasPrefix(line, "{"):
+			if m, ok := parseJSONObject(line); ok {
+				if _, journal := m["__REALTIME_TIMESTAMP"]; journal {
+					counts[Journal]++
+				} else {
+					counts[JSONLines]++
+				}
+			}
+		case accessRe.MatchString(line):
+			counts[AccessLog]++
+		default:
+			if _, ok := parseLogfmt(line); ok {
+				counts[Logfmt]++
+			}
+		}
+	}
+	for _, f := range []Format{Journal, JSONLines, Logfmt, AccessLog} {
+		if counts[f]*2 > sampled {
+			return f
+		}
+	}
+	return Plain
+}
+
+// Level is a normalized log level. Levels compare by severity.
+type Level int
+
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+var levelNames = []string{"", "TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// String returns the upper-case name of the level, or "" if unknown.
+func (l Level) String() string { return levelNames[l] }
+
+// ParseLevel maps the level names used by common loggers (and syslog) onto
+// a Level: "warning" is LevelWarn, "crit" and "panic" are LevelFatal. It
+// also understands pino and bunyan's numeric levels (10-60).
+func ParseLevel(s string) (Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace":
+		return LevelTrace, true
+	case "debug", "dbug", "dbg", "verbose":
+		return LevelDebug, true
+	case "info", "information", "informational", "notice":
+		return LevelInfo, true
+	case "warn", "warning":
+		return LevelWarn, true
+	case "error", "err", "eror":
+		return LevelError, true
+	case "fatal", "critical", "crit", "panic", "dpanic", "alert", "emerg", "emergency", "severe":
+		return LevelFatal, true
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 10 {
+		return min(LevelFatal, Level(n/10)), true
+	}
+	return LevelUnknown, false
+}
+
+// syslogLevels maps journald's PRIORITY (syslog severity 0-7) to a Level.
+var syslogLevels = []Level{LevelFatal, LevelFatal, LevelFatal, LevelError, LevelWarn, LevelInfo, LevelInfo, LevelDebug}
+
+// Field is a key and value of a structured log record.
+type Field struct {
+	Key, Value string
+}
+
+// Record is one parsed line of a structured log.
+type Record struct {
+	Time    time.Time
+	Level   Level
+	Service string
+	Message string
+
+	// Fields holds every key of the line (sorted by key for JSON),
+	// including those read into the fields above.
+	Fields []Field
+}
+
+// Field returns the value of key, compared case-insensitively. The keys
+// "service", "level" and "message" also match the normalized values.
+func (r Record) Field(key string) (string, bool) {
+	for _, f := range r.Fields {
+		if strings.EqualFold(f.Key, key) {
+			return f.Value, true
+		}
+	}
+	switch strings.ToLower(key) {
+	case "service":
+		return r.Service, r.Service != ""
+	case "level":
+		return r.Level.String(), r.Level != LevelUnknown
+	case "message", "msg":
+		return r.Message, r.Message != ""
+	}
+	return "", false
+}
+
+// Well-known keys, in order of preference. journald's upper-case keys and
+// the dotted keys of nested objects (ECS "log.level") are included.
+var (
+	timeKeys    = []string{"__REALTIME_TIMESTAMP", "@timestamp", "timestamp", "time", "ts", "t", "datetime", "date"}
+	levelKeys   = []string{"PRIORITY", "level", "lvl", "severity", "log.level", "levelname", "loglevel", "@level"}
+	serviceKeys = []string{"service", "service.name", "svc", "app", "application", "component", "SYSLOG_IDENTIFIER", "_SYSTEMD_UNIT", "logger", "logger_name", "name"}
+	messageKeys = []string{"MESSAGE", "msg", "message", "@message", "text", "event", "log"}
+
+	wellKnown = func() map[string]bool {
+		m := make(map[string]bool)
+		for _, keys := range [][]string{timeKeys, levelKeys, serviceKeys, messageKeys} {
+			for _, k := range keys {
+				m[k] = true
+			}
+		}
+		return m
+	}()
+)
+
+// errorKeyRe matches the keys of fields that describe a failure and are
+// worth keeping in the compact view, on their own or as part of a dotted
+// key ("error.message", "http.status_code").
+var errorKeyRe = regexp.MustCompile(`(?i)^(err|error|errors|errno|exception|exc_info|stack|stacktrace|stack_trace|trace|cause|reason|status|status_code|code|exit_code|exit_status)$`)
+
+func isErrorKey(key string) bool {
+	for _, part := range strings.Split(key, ".") {
+		if errorKeyRe.MatchString(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// newRecord fills the normalized fields of a record from its key/value pairs.
+func newRecord(fields []Field) Record {
+	r := Record{Fields: fields}
+	get := func(keys []string) (string, string) {
+		for _, k := range keys {
+			for _, f := range fields {
+				if f.Key == k && f.Value != "" {
+					return k, f.Value
+				}
+			}
+		}
+		return "", ""
+	}
+	if k, v := get(timeKeys); k != "" {
+		r.Time = parseTime(k, v)
+	}
+	if k, v := get(levelKeys); k == "PRIORITY" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 && n < len(syslogLevels) {
+			r.Level = syslogLevels[n]
+		}
+	} else if k != "" {
+		r.Level, _ = ParseLevel(v)
+	}
+	_, r.Service = get(serviceKeys)
+	_, r.Message = get(messageKeys)
+	return r
+}
+
+// timeLayouts are the timestamp formats tried for string values.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"02/Jan/2006:15:04:05 -0700",
+}
+
+// parseTime parses a timestamp value: a date string, or a Unix time in
+// seconds (possibly fractional, as zap writes it), milliseconds,
+// microseconds (journald) or nanoseconds, told apart by magnitude.
+func parseTime(key, v string) time.Time {
+	if key == "__REALTIME_TIMESTAMP" {
+		if us, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.UnixMicro(us)
+		}
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		switch {
+		case f > 1e17:
+			return time.Unix(0, int64(f))
+		case f > 1e14:
+			return time.UnixMicro(int64(f))
+		case f > 1e11:
+			return time.UnixMilli(int64(f))
+		case f > 1e8:
+			sec := int64(f)
+			return time.Unix(sec, int64((f-float64(sec))*1e9)).Round(time.Millisecond)
+		}
+		return time.Time{}
+	}
+	for _, layout := range timeLayouts {
+		// Comma decimal separators, as Python's logging writes them. Times
+		// without a zone are taken to be local, like the log's machine.
+		if t, err := time.ParseInLocation(layout, strings.Replace(v, ",", ".", 1), time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseJSONObject decodes a JSON object, keeping numbers as written.
+func parseJSONObject(line string) (map[string]any, bool) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil || m == nil {
+		return nil, false
+	}
+	return m, true
+}
+
+// parseJSON parses a JSON line into fields sorted by key, with nested
+// objects flattened to dotted keys ("error.message").
+func parseJSON(line string) ([]Field, bool) {
+	m, ok := parseJSONObject(line)
+	if !ok {
+		return nil, false
+	}
+	var fields []Field
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			switch v := v.(type) {
+			case map[string]any:
+				flatten(prefix+k+".", v)
+			case string:
+				fields = append(fields, Field{prefix + k, v})
+			case nil:
+			default:
+				b, _ := json.Marshal(v)
+				fields = append(fields, Field{prefix + k, string(b)})
+			}
+		}
+	}
+	flatten("", m)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields, true
+}
+
+// logfmtKeyRe matches a logfmt key followed by "=".
+var logfmtKeyRe = regexp.MustCompile(`^[\w@.:/-]+=`)
+
+// parseLogfmt parses key=value pairs, where values may be double-quoted
+// with Go escapes. A line is only taken as logfmt when it starts with a
+// pair and has at least two of them.
+func parseLogfmt(line string) ([]Field, bool) {
+	if !logfmtKeyRe.MatchString(line) {
+		return nil, false
+	}
+	var fields []Field
+	pairs := 0
+	for s := line; ; {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+		end := strings.IndexAny(s, "= \t")
+		if end < 0 {
+			end = len(s)
+		}
+		key := s[:end]
+		s = s[end:]
+		if key == "" || !strings.HasPrefix(s, "=") {
+			// A bare key, or a stray "=": keep it as a flag.
+			if key == "" {
+				s = s[1:]
+				continue
+			}
+			fields = append(fields, Field{key, "true"})
+			continue
+		}
+		s = s[1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			i := 1
+			for i < len(s) && s[i] != '"' {
+				if s[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(s) {
+				return nil, false // unterminated quote
+			}
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				v = s[1:i]
+			}
+			value, s = v, s[i+1:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		fields = append(fields, Field{key, value})
+		pairs++
+	}
+	return fields, pairs >= 2
+}
+
+// accessRe matches the Common Log Format and its Combined extension:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://ref/" "Mozilla/5.0"
+var accessRe = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) (\S+)(?: "([^"]*)" "([^"]*)")?`)
+
+// parseAccess parses an access log line. Its level follows the status:
+// 5xx is an error and 4xx a warning.
+func parseAccess(line string) (Record, bool) {
+	m := accessRe.FindStringSubmatch(line)
+	if m == nil {
+		return Record{}, false
+	}
+	r := Record{Message: m[4]}
+	r.Time, _ = time.Parse("02/Jan/2006:15:04:05 -0700", m[3])
+	switch m[5][0] {
+	case '5':
+		r.Level = LevelError
+	case '4':
+		r.Level = LevelWarn
+	default:
+		r.Level = LevelInfo
+	}
+	r.Fields = []Field{{"client", m[1]}, {"status", m[5]}, {"bytes", m[6]}}
+	if m[2] != "-" {
+		r.Fields = append(r.Fields, Field{"user", m[2]})
+	}
+	if m[7] != "" && m[7] != "-" {
+		r.Fields = append(r.Fields, Field{"referer", m[7]})
+	}
+	if m[8] != "" && m[8] != "-" {
+		r.Fields = append(r.Fields, Field{"user_agent", m[8]})
+	}
+	return r, true
+}
+
+// Parse parses one line of a log in format f.
+func Parse(line string, f Format) (Record, bool) {
+	var (
+		fields []Field
+		ok     bool
+	)
+	switch f {
+	case JSONLines, Journal:
+		fields, ok = parseJSON(strings.TrimSpace(line))
+	case Logfmt:
+		fields, ok = parseLogfmt(strings.TrimSpace(line))
+	case AccessLog:
+		return parseAccess(line)
+	}
+	if !ok {
+		return Record{}, false
+	}
+	return newRecord(fields), true
+}
+
+// Filter selects records of a structured log. The zero Filter keeps all.
+type Filter struct {
+	// MinLevel drops records below this level, and those without a level.
+	MinLevel Level
+
+	// Since drops records older than this, and those without a timestamp.
+	Since time.Time
+
+	// Fields requires each key to have the given value (compared
+	// case-insensitively), e.g. service=api.
+	Fields []Field
+}
+
+// Active reports whether the filter drops anything.
+func (f Filter) Active() bool {
+	return f.MinLevel != LevelUnknown || !f.Since.IsZero() || len(f.Fields) > 0
+}
+
+// Match reports whether the filter keeps r.
+func (f Filter) Match(r Record) bool {
+	if f.MinLevel != LevelUnknown && r.Level < f.MinLevel {
+		return false
+	}
+	if !f.Since.IsZero() && (r.Time.IsZero() || r.Time.Before(f.Since)) {
+		return false
+	}
+	for _, want := range f.Fields {
+		if v, ok := r.Field(want.Key); !ok || !strings.EqualFold(v, want.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseFieldFilter parses a "key=value" filter.
+func ParseFieldFilter(s string) (Field, error) {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return Field{}, fmt.Errorf("invalid field filter %q: use key=value, e.g. service=api", s)
+	}
+	return Field{strings.TrimSpace(key), strings.TrimSpace(value)}, nil
+}
+
+// Normalized is a structured log rendered by Normalize.
+type Normalized struct {
+	Text   string
+	Format Format
+
+	// Records counts the parsed lines, Kept those that passed the filter.
+	Records, Kept int
+}
+
+// Normalize parses text as a structured log and renders each record the
+// filter keeps on one compact line:
+//
+//	2024-05-01 10:00:00 ERROR [api] query failed error="dial tcp: i/o timeout"
+//
+// Only the timestamp, level, service, message and the fields describing a
+// failure (error, stack, status, ...) are kept; multi-line values such as
+// stack traces follow on indented lines. Lines that do not parse, such as
+// a stack trace printed raw, stay with the record before them. It returns
+// false if text is not a structured log.
+func Normalize(text string, f Filter) (Normalized, bool) {
+	n := Normalized{Format: DetectFormat(text)}
+	if n.Format == Plain {
+		return n, false
+	}
+	var b strings.Builder
+	keep := !f.Active() // for lines before the first record
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		r, ok := Parse(line, n.Format)
+		if !ok {
+			if keep && strings.TrimSpace(line) != "" {
+				b.WriteString(line + "\n")
+			}
+			continue
+		}
+		n.Records++
+		if keep = f.Match(r); keep {
+			n.Kept++
+			writeRecord(&b, r)
+		}
+	}
+	n.Text = b.String()
+	return n, true
+}
+
+// writeRecord renders r as one line, plus indented lines for multi-line
+// field values.
+func writeRecord(b *strings.Builder, r Record) {
+	var parts, blocks []string
+	if !r.Time.IsZero() {
+		parts = append(parts, r.Time.Format("2006-01-02 15:04:05"))
+	}
+	if r.Level != LevelUnknown {
+		parts = append(parts, r.Level.String())
+	}
+	if r.Service != "" {
+		parts = append(parts, "["+r.Service+"]")
+	}
+	msg, rest, multi := strings.Cut(strings.TrimRight(r.Message, "\n"), "\n")
+	if msg != "" {
+		parts = append(parts, msg)
+	}
+	if multi {
+		blocks = append(blocks, rest)
+	}
+	for _, fd := range r.Fields {
+		if wellKnown[fd.Key] || !isErrorKey(fd.Key) || fd.Value == "" {
+			continue
+		}
+		if v := strings.TrimRight(fd.Value, "\n"); strings.Contains(v, "\n") {
+			blocks = append(blocks, fd.Key+":\n"+v)
+			continue
+		}
+		parts = append(parts, fd.Key+"="+quoteValue(fd.Value))
+	}
+	b.WriteString(strings.Join(parts, " ") + "\n")
+	for _, block := range blocks {
+		for _, l := range strings.Split(block, "\n") {
+			b.WriteString("    " + l + "\n")
+		}
+	}
+}
+
+// quoteValue quotes v if it is empty or contains spaces, quotes or "=".
+func quoteValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\"=") {
+		return strconv.Quote(v)
+	}
+	return v
+}
@@ -0,0 +1,129 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Format
+	}{
+		{"json", `{"level":"info","msg":"up"}` + "\n" + `{"level":"error","msg":"down"}`, JSONLines},
+		{"journal", `{"__REALTIME_TIMESTAMP":"1714557600000000","PRIORITY":"3","MESSAGE":"boom"}`, Journal},
+		{"logfmt", "time=2024-05-01T10:00:00Z level=info msg=up\nlevel=error msg=\"db down\"", Logfmt},
+		{"access", `10.0.0.1 - - [01/May/2024:10:00:00 +0000] "GET / HTTP/1.1" 200 512 "-" "curl/8.0"`, AccessLog},
+		{"plain", "starting server\nERROR: failed to bind port=8080", Plain},
+		// A raw stack trace between JSON lines does not hide the format.
+		{"mixed", `{"msg":"a"}` + "\npanic: boom\n" + `{"msg":"b"}`, JSONLines},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.text); got != tt.want {
+			t.Errorf("%s: DetectFormat = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{
+		"WARNING": LevelWarn, "err": LevelError, "crit": LevelFatal,
+		"notice": LevelInfo, "30": LevelInfo, "50": LevelError, "60": LevelFatal,
+	}
+	for s, want := range tests {
+		if got, ok := ParseLevel(s); !ok || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", s, got, ok, want)
+		}
+	}
+	if _, ok := ParseLevel("loud"); ok {
+		t.Error("ParseLevel accepted an unknown level")
+	}
+}
+
+func TestParse(t *testing.T) {
+	r, ok := Parse(`{"ts":1714557600.25,"level":50,"name":"api","msg":"query failed","err":{"message":"timeout"},"req_id":"a1"}`, JSONLines)
+	if !ok || r.Level != LevelError || r.Service != "api" || r.Message != "query failed" ||
+		!r.Time.Equal(time.Unix(1714557600, 250e6)) {
+		t.Errorf("JSON: unexpected record %+v", r)
+	}
+	if v, _ := r.Field("err.message"); v != "timeout" {
+		t.Errorf("nested field err.message = %q", v)
+	}
+
+	r, ok = Parse(`{"__REALTIME_TIMESTAMP":"1714557600000000","PRIORITY":"2","SYSLOG_IDENTIFIER":"sshd","MESSAGE":"fatal"}`, Journal)
+	if !ok || r.Level != LevelFatal || r.Service != "sshd" || !r.Time.Equal(time.Unix(1714557600, 0)) {
+		t.Errorf("journal: unexpected record %+v", r)
+	}
+
+	r, ok = Parse(`time="2024-05-01 10:00:00,123" level=warning msg="disk \"data\" at 91%" component=store`, Logfmt)
+	if !ok || r.Level != LevelWarn || r.Service != "store" || r.Message != `disk "data" at 91%` ||
+		r.Time.Nanosecond() != 123e6 {
+		t.Errorf("logfmt: unexpected record %+v", r)
+	}
+
+	r, ok = Parse(`10.0.0.1 - bob [01/May/2024:10:00:00 +0200] "POST /api HTTP/1.1" 502 0 "-" "curl/8.0"`, AccessLog)
+	if !ok || r.Level != LevelError || r.Message != "POST /api HTTP/1.1" || !r.Time.Equal(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("access: unexpected record %+v", r)
+	}
+	if v, _ := r.Field("user_agent"); v != "curl/8.0" {
+		t.Errorf("user_agent = %q", v)
+	}
+
+	if _, ok := Parse(`level=info msg="unterminated`, Logfmt); ok {
+		t.Error("parsed a logfmt line with an unterminated quote")
+	}
+}
+
+// TestNormalize_Structured verifies the compact rendering, the filter and
+// that raw lines stay with the record before them.
+func TestNormalize_Structured(t *testing.T) {
+	text := strings.Join([]string{
+		`{"time":"2024-05-01T10:00:00Z","level":"info","service":"api","msg":"request served","path":"/","status":200}`,
+		`{"time":"2024-05-01T10:00:01Z","level":"error","service":"api","msg":"query failed","error":"dial tcp: i/o timeout","user":"x"}`,
+		`goroutine 1 [running]:`,
+		`{"time":"2024-05-01T10:00:02Z","level":"error","service":"worker","msg":"job failed","stack":"main.run()\n\tmain.go:12"}`,
+		`{"time":"2024-05-01T10:00:03Z","level":"warn","service":"api","msg":"slow"}`,
+	}, "\n")
+
+	n, ok := Normalize(text, Filter{})
+	if !ok || n.Format != JSONLines || n.Records != 4 || n.Kept != 4 {
+		t.Fatalf("unexpected result %+v", n)
+	}
+	want := `2024-05-01 10:00:00 INFO [api] request served status=200
+2024-05-01 10:00:01 ERROR [api] query failed error="dial tcp: i/o timeout"
+goroutine 1 [running]:
+2024-05-01 10:00:02 ERROR [worker] job failed
+    stack:
+    main.run()
+    	main.go:12
+2024-05-01 10:00:03 WARN [api] slow
+`
+	if n.Text != want {
+		t.Errorf("Text =\n%s\nwant\n%s", n.Text, want)
+	}
+
+	n, _ = Normalize(text, Filter{
+		MinLevel: LevelError,
+		Since:    time.Date(2024, 5, 1, 10, 0, 1, 0, time.UTC),
+		Fields:   []Field{{"service", "API"}},
+	})
+	want = "2024-05-01 10:00:01 ERROR [api] query failed error=\"dial tcp: i/o timeout\"\ngoroutine 1 [running]:\n"
+	if n.Kept != 1 || n.Text != want {
+		t.Errorf("filtered: Kept=%d Text=\n%s", n.Kept, n.Text)
+	}
+
+	if _, ok := Normalize("just text\n", Filter{}); ok {
+		t.Error("plain text was normalized")
+	}
+}
+
+func TestParseFieldFilter(t *testing.T) {
+	if f, err := ParseFieldFilter("service = api"); err != nil || f != (Field{"service", "api"}) {
+		t.Errorf("got %+v, %v", f, err)
+	}
+	if _, err := ParseFieldFilter("service"); err == nil {
+		t.Error("expected an error without '='")
+	}
+}
@@ -13,6 +13,7 @@ import (
 type CommandStats struct {
 	Runs        int       `json:"runs"`
 	Failures    int       `json:"failures"`
+	Cancelled   int       `json:"cancelled,omitempty"`
 	TotalTimeMs int64     `json:"total_time_ms"`
 	AvgTimeMs   int64     `json:"avg_time_ms"`
 	LastRun     time.Time `json:"last_run"`
@@ -70,3 +71,21 @@ func Record(cmd string, elapsed time.Duration, errMsg string) {
 
 	_ = s.Save() // Best-effort — don't crash if we can't write metrics
 }
+
+// RecordCancelled updates stats for a run the user interrupted (Ctrl-C or
+// SIGTERM). The run is counted but not treated as a failure.
+func RecordCancelled(cmd string, elapsed time.Duration) {
+	s := Load()
+
+	if s[cmd] == nil {
+		s[cmd] = &CommandStats{}
+	}
+	stat := s[cmd]
+	stat.Runs++
+	stat.Cancelled++
+	stat.TotalTimeMs += elapsed.Milliseconds()
+	stat.AvgTimeMs = stat.TotalTimeMs / int64(stat.Runs)
+	stat.LastRun = time.Now()
+
+	_ = s.Save()
+}
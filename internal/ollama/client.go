@@ -3,6 +3,7 @@ package ollama
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -52,36 +53,46 @@ func NewClient(modelOverride string) *Client {
 	}
 }
 
-type GenerateRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+// Message is a single chat turn in the /api/chat wire format.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
 }
 
-type GenerateResponse struct {
-	Model    string `json:"model"`
-	Created  string `json:"created_at"`
-	Response string `json:"response"`
-	Done     bool   `json:"done"`
+// ModelOptions is the "options" object of an Ollama request.
+type ModelOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
 }
 
-// Generate sends a prompt and waits for the full response (non-streaming).
-// Kept for use in tests and stats.
-func (c *Client) Generate(prompt string) (string, error) {
-	reqBody := GenerateRequest{
-		Model:  c.Model,
-		Prompt: prompt,
-		Stream: false,
-	}
+type ChatRequest struct {
+	Model     string        `json:"model"`
+	Messages  []Message     `json:"messages"`
+	Stream    bool          `json:"stream"`
+	Options   *ModelOptions `json:"options,omitempty"`
+	KeepAlive string        `json:"keep_alive,omitempty"`
+}
+
+type ChatResponse struct {
+	Model   string  `json:"model"`
+	Created string  `json:"created_at"`
+	Message Message `json:"message"`
+	Done    bool    `json:"done"`
+}
 
-	jsonData, err := json.Marshal(reqBody)
+// Chat sends a chat request to /api/chat and waits for the full response
+// (non-streaming).
+func (c *Client) Chat(ctx context.Context, req provider.ChatRequest) (string, error) {
+	jsonData, err := json.Marshal(c.buildRequest(req, false))
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := c.HTTP.Post(c.BaseURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := c.post(ctx, jsonData)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request to Ollama: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
@@ -89,32 +100,30 @@ func (c *Client) Generate(prompt string) (string, error) {
 		return "", err
 	}
 
-	var genResp GenerateResponse
-	if err := json.NewDecoder(resp.Body).Decode(&genResp); err != nil {
+	var chatResp ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return genResp.Response, nil
+	return chatResp.Message.Content, nil
 }
 
-// GenerateStream sends a prompt and calls onChunk for every token received,
+// ChatStream sends a chat request and calls onChunk for every token received,
 // allowing the caller to print text as it arrives. It returns the full
 // accumulated response string so callers can use it (e.g. for clipboard copy).
-func (c *Client) GenerateStream(prompt string, onChunk func(token string)) (string, error) {
-	reqBody := GenerateRequest{
-		Model:  c.Model,
-		Prompt: prompt,
-		Stream: true,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
+// Cancelling ctx closes the connection and returns the partial response.
+func (c *Client) ChatStream(ctx context.Context, req provider.ChatRequest, onChunk func(token string)) (string, error) {
+	jsonData, err := json.Marshal(c.buildRequest(req, true))
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := c.HTTP.Post(c.BaseURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
+	resp, err := c.post(ctx, jsonData)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request to Ollama: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
@@ -129,19 +138,22 @@ func (c *Client) GenerateStream(prompt string, onChunk func(token string)) (stri
 		if len(line) == 0 {
 			continue
 		}
-		var chunk GenerateResponse
+		var chunk ChatResponse
 		if err := json.Unmarshal(line, &chunk); err != nil {
 			continue // skip malformed lines
 		}
-		if chunk.Response != "" {
-			onChunk(chunk.Response)
-			full += chunk.Response
+		if token := chunk.Message.Content; token != "" {
+			onChunk(token)
+			full += token
 		}
 		if chunk.Done {
 			break
 		}
 	}
 
+	if ctx.Err() != nil {
+		return full, ctx.Err()
+	}
 	if err := scanner.Err(); err != nil && err != io.EOF {
 		return full, fmt.Errorf("error reading stream: %w", err)
 	}
@@ -149,9 +161,64 @@ func (c *Client) GenerateStream(prompt string, onChunk func(token string)) (stri
 	return full, nil
 }
 
+// Generate sends a single-prompt request and waits for the full response.
+// It is a compatibility shim over Chat.
+func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.Chat(ctx, provider.Prompt(prompt))
+}
+
+// GenerateStream streams the response to a single prompt.
+// It is a compatibility shim over ChatStream.
+func (c *Client) GenerateStream(ctx context.Context, prompt string, onChunk func(token string)) (string, error) {
+	return c.ChatStream(ctx, provider.Prompt(prompt), onChunk)
+}
+
+// buildRequest converts a provider.ChatRequest into the /api/chat wire format,
+// emitting the system prompt as the leading "system" message and mapping the
+// generation options to Ollama's "options" object and "keep_alive".
+func (c *Client) buildRequest(req provider.ChatRequest, stream bool) ChatRequest {
+	msgs := make([]Message, 0, len(req.Messages)+1)
+	if req.System != "" {
+		msgs = append(msgs, Message{Role: string(provider.RoleSystem), Content: req.System})
+	}
+	for _, m := range req.Messages {
+		msgs = append(msgs, Message{Role: string(m.Role), Content: m.Content})
+	}
+	out := ChatRequest{Model: c.Model, Messages: msgs, Stream: stream, KeepAlive: req.Options.KeepAlive}
+	o := req.Options
+	if o.Temperature != nil || o.NumCtx > 0 || o.Seed != nil || len(o.Stop) > 0 {
+		out.Options = &ModelOptions{Temperature: o.Temperature, NumCtx: o.NumCtx, Seed: o.Seed, Stop: o.Stop}
+	}
+	return out
+}
+
+// post sends a JSON body to /api/chat bound to ctx.
+func (c *Client) post(ctx context.Context, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/chat", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to send request to Ollama: %w", err)
+	}
+	return resp, nil
+}
+
 // Name implements provider.Provider and identifies this backend.
 func (c *Client) Name() string { return "ollama" }
 
+// ModelName implements provider.Provider and returns the configured model.
+func (c *Client) ModelName() string { return c.Model }
+
+// Endpoint implements provider.Endpointer and returns the base URL.
+func (c *Client) Endpoint() string { return c.BaseURL }
+
 // init registers the Ollama backend with the global provider registry so that
 // any package that blank-imports this package (e.g. main) gets the factory
 // available at startup — the standard database/sql driver pattern.
@@ -1,10 +1,15 @@
 package ollama
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/shell-sage/internal/provider"
 )
 
 // newTestServer creates a local HTTP test server that mimics the Ollama API.
@@ -13,7 +18,7 @@ func newTestServer(t *testing.T, response string, statusCode int) *httptest.Serv
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(statusCode)
 		if response != "" {
-			resp := GenerateResponse{Response: response, Done: true}
+			resp := ChatResponse{Message: Message{Role: "assistant", Content: response}, Done: true}
 			_ = json.NewEncoder(w).Encode(resp)
 		} else {
 			_, _ = w.Write([]byte(`{"error":"model not found"}`))
@@ -32,7 +37,7 @@ func TestGenerate_HappyPath(t *testing.T) {
 		HTTP:    &http.Client{},
 	}
 
-	result, err := client.Generate("Explain ls -la")
+	result, err := client.Generate(context.Background(), "Explain ls -la")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -41,6 +46,90 @@ func TestGenerate_HappyPath(t *testing.T) {
 	}
 }
 
+// TestChat_SystemAndHistory verifies that the system prompt and message
+// history are sent to /api/chat in order.
+func TestChat_SystemAndHistory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %v", err)
+		}
+		var roles []string
+		for _, m := range req.Messages {
+			roles = append(roles, m.Role)
+		}
+		if got := strings.Join(roles, ","); got != "system,user,assistant,user" {
+			t.Errorf("unexpected roles: %s", got)
+		}
+		if req.Messages[0].Content != "Be terse." {
+			t.Errorf("unexpected system prompt: %q", req.Messages[0].Content)
+		}
+		_ = json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: "ok"}, Done: true})
+	}))
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, Model: "testmodel", HTTP: &http.Client{}}
+	result, err := client.Chat(context.Background(), provider.ChatRequest{
+		System: "Be terse.",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "What is ls?"},
+			{Role: provider.RoleAssistant, Content: "It lists files."},
+			{Role: provider.RoleUser, Content: "And -la?"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "ok" {
+		t.Errorf("unexpected response: %q", result)
+	}
+}
+
+// TestChat_Options verifies that generation options are mapped to Ollama's
+// "options" object and top-level "keep_alive".
+func TestChat_Options(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("could not decode request: %v", err)
+		}
+		_ = json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: "ok"}, Done: true})
+	}))
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, Model: "testmodel", HTTP: &http.Client{}}
+
+	temp, seed := 0.0, 42
+	req := provider.Prompt("hi")
+	req.Options = provider.Options{Temperature: &temp, Seed: &seed, NumCtx: 8192, Stop: []string{"###"}, KeepAlive: "10m"}
+	if _, err := client.Chat(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts, ok := body["options"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected options object, got body %v", body)
+	}
+	if opts["temperature"] != 0.0 || opts["seed"] != 42.0 || opts["num_ctx"] != 8192.0 {
+		t.Errorf("unexpected options: %v", opts)
+	}
+	if body["keep_alive"] != "10m" {
+		t.Errorf("expected keep_alive 10m, got %v", body["keep_alive"])
+	}
+
+	// Without options the object must be omitted entirely.
+	body = nil
+	if _, err := client.Chat(context.Background(), provider.Prompt("hi")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, present := body["options"]; present {
+		t.Errorf("expected no options object, got %v", body["options"])
+	}
+}
+
 // TestGenerate_ModelNotFound verifies that a 404 returns a helpful error message.
 func TestGenerate_ModelNotFound(t *testing.T) {
 	srv := newTestServer(t, "", http.StatusNotFound)
@@ -52,7 +141,7 @@ func TestGenerate_ModelNotFound(t *testing.T) {
 		HTTP:    &http.Client{},
 	}
 
-	_, err := client.Generate("Explain ls")
+	_, err := client.Generate(context.Background(), "Explain ls")
 	if err == nil {
 		t.Fatal("expected an error for 404, got nil")
 	}
@@ -104,3 +193,30 @@ func containsRune(s, substr string) bool {
 	}
 	return false
 }
+
+// TestGenerateStream_Cancel verifies that cancelling the context aborts an
+// in-flight stream and surfaces context.Canceled with the partial response.
+func TestGenerateStream_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(ChatResponse{Message: Message{Role: "assistant", Content: "partial"}})
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := &Client{BaseURL: srv.URL, Model: "testmodel", HTTP: &http.Client{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	full, err := client.GenerateStream(ctx, "Explain ls", func(string) { cancel() })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if full != "partial" {
+		t.Errorf("expected partial response to be returned, got %q", full)
+	}
+}
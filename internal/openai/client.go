@@ -0,0 +1,273 @@
+// Package openai implements provider.Provider against any server that speaks
+// the OpenAI /v1/chat/completions protocol, such as llama.cpp's server, vLLM
+// and LM Studio.
+//
+// Configuration is read from the environment:
+//
+//	SSAGE_OPENAI_BASE_URL  base URL including the /v1 prefix (default http://localhost:8080/v1)
+//	SSAGE_OPENAI_API_KEY   optional bearer token (falls back to OPENAI_API_KEY)
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shell-sage/internal/config"
+	"github.com/shell-sage/internal/provider"
+)
+
+const (
+	DefaultBaseURL = "http://localhost:8080/v1"
+	DefaultModel   = "local-model"
+)
+
+type Client struct {
+	BaseURL string
+	APIKey  string
+	Model   string
+	HTTP    *http.Client
+}
+
+// NewClient creates a new OpenAI-compatible client.
+// Model priority: modelOverride > SSAGE_MODEL > config file > DefaultModel.
+// BaseURL and APIKey are read from SSAGE_OPENAI_BASE_URL and
+// SSAGE_OPENAI_API_KEY (or OPENAI_API_KEY).
+func NewClient(modelOverride string) *Client {
+	model := modelOverride
+	if model == "" {
+		model = os.Getenv("SSAGE_MODEL")
+	}
+
+	if model == "" {
+		cfg, err := config.Load()
+		if err == nil && cfg.Model != "" {
+			model = cfg.Model
+		}
+	}
+
+	if model == "" {
+		model = DefaultModel
+	}
+
+	baseURL := os.Getenv("SSAGE_OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	apiKey := os.Getenv("SSAGE_OPENAI_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Model:   model,
+		HTTP: &http.Client{
+			Timeout: 120 * time.Second, // Longer timeout for streaming
+		},
+	}
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest is the chat-completions request body. NumCtx and KeepAlive
+// have no equivalent in the protocol and are not sent.
+type ChatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Stream      bool      `json:"stream"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	Seed        *int      `json:"seed,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+}
+
+type ChatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+}
+
+// StreamChunk is a single server-sent event payload of a streaming response.
+type StreamChunk struct {
+	Choices []struct {
+		Delta        Message `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// Chat sends a chat request and waits for the full response (non-streaming).
+func (c *Client) Chat(ctx context.Context, req provider.ChatRequest) (string, error) {
+	resp, err := c.post(ctx, req, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var chatResp ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("openai API returned no choices")
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// ChatStream sends a chat request and calls onChunk for every token received
+// over the server-sent events stream. It returns the full accumulated
+// response string. Cancelling ctx closes the connection and returns the
+// partial response.
+func (c *Client) ChatStream(ctx context.Context, req provider.ChatRequest, onChunk func(token string)) (string, error) {
+	resp, err := c.post(ctx, req, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// SSE frames look like "data: {...}"; comments and other fields are ignored.
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue // skip malformed frames
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if token := chunk.Choices[0].Delta.Content; token != "" {
+			onChunk(token)
+			full.WriteString(token)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return full.String(), ctx.Err()
+	}
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return full.String(), fmt.Errorf("error reading stream: %w", err)
+	}
+
+	return full.String(), nil
+}
+
+// Generate sends a single-prompt request and waits for the full response.
+// It is a compatibility shim over Chat.
+func (c *Client) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.Chat(ctx, provider.Prompt(prompt))
+}
+
+// GenerateStream streams the response to a single prompt.
+// It is a compatibility shim over ChatStream.
+func (c *Client) GenerateStream(ctx context.Context, prompt string, onChunk func(token string)) (string, error) {
+	return c.ChatStream(ctx, provider.Prompt(prompt), onChunk)
+}
+
+// Name implements provider.Provider and identifies this backend.
+func (c *Client) Name() string { return "openai" }
+
+// ModelName implements provider.Provider and returns the configured model.
+func (c *Client) ModelName() string { return c.Model }
+
+// Endpoint implements provider.Endpointer and returns the base URL.
+func (c *Client) Endpoint() string { return c.BaseURL }
+
+// init registers the OpenAI-compatible backend with the global provider
+// registry under the name "openai".
+func init() {
+	provider.Register("openai", func(model string) (provider.Provider, error) {
+		return NewClient(model), nil
+	})
+}
+
+// post sends a chat-completions request and returns the response after
+// validating its status. The system prompt becomes the leading "system"
+// message. The request is bound to ctx.
+func (c *Client) post(ctx context.Context, chat provider.ChatRequest, stream bool) (*http.Response, error) {
+	msgs := make([]Message, 0, len(chat.Messages)+1)
+	if chat.System != "" {
+		msgs = append(msgs, Message{Role: string(provider.RoleSystem), Content: chat.System})
+	}
+	for _, m := range chat.Messages {
+		msgs = append(msgs, Message{Role: string(m.Role), Content: m.Content})
+	}
+	reqBody := ChatRequest{
+		Model:       c.Model,
+		Messages:    msgs,
+		Stream:      stream,
+		Temperature: chat.Options.Temperature,
+		Seed:        chat.Options.Seed,
+		Stop:        chat.Options.Stop,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to send request to %s: %w", c.BaseURL, err)
+	}
+
+	if err := checkStatus(resp, c.Model); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// checkStatus returns a descriptive error for non-200 HTTP responses.
+func checkStatus(resp *http.Response, model string) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("openai API rejected the request (status %d). Check SSAGE_OPENAI_API_KEY", resp.StatusCode)
+	case http.StatusNotFound:
+		return fmt.Errorf("model '%s' or endpoint not found. Check the model name and SSAGE_OPENAI_BASE_URL (it must include /v1)", model)
+	}
+	return fmt.Errorf("openai API returned status %d: %s", resp.StatusCode, string(body))
+}
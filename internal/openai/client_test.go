@@ -0,0 +1,148 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestServer creates a local HTTP test server that mimics the
+// /v1/chat/completions endpoint in non-streaming mode.
+func newTestServer(t *testing.T, response string, statusCode int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(statusCode)
+		if response != "" {
+			_, _ = fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, response)
+		} else {
+			_, _ = w.Write([]byte(`{"error":{"message":"not found"}}`))
+		}
+	}))
+}
+
+// TestGenerate_HappyPath verifies the client correctly receives an AI response.
+func TestGenerate_HappyPath(t *testing.T) {
+	srv := newTestServer(t, "This command lists files.", http.StatusOK)
+	defer srv.Close()
+
+	client := &Client{
+		BaseURL: srv.URL + "/v1",
+		Model:   "testmodel",
+		HTTP:    &http.Client{},
+	}
+
+	result, err := client.Generate(context.Background(), "Explain ls -la")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result != "This command lists files." {
+		t.Errorf("unexpected response: %q", result)
+	}
+}
+
+// TestGenerateStream_SSE verifies that SSE deltas are forwarded in order and
+// that the [DONE] sentinel terminates the stream.
+func TestGenerateStream_SSE(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("could not decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("expected stream=true in request body")
+		}
+		if len(req.Messages) != 1 || req.Messages[0].Role != "user" || req.Messages[0].Content != "hi" {
+			t.Errorf("unexpected messages: %+v", req.Messages)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, tok := range []string{"Hel", "lo", "!"} {
+			_, _ = fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", tok)
+		}
+		_, _ = fmt.Fprint(w, ": keep-alive comment\n\n")
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+		_, _ = fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ignored\"}}]}\n\n")
+	}))
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, Model: "testmodel", HTTP: &http.Client{}}
+
+	var chunks []string
+	full, err := client.GenerateStream(context.Background(), "hi", func(tok string) { chunks = append(chunks, tok) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if full != "Hello!" {
+		t.Errorf("unexpected full response: %q", full)
+	}
+	if strings.Join(chunks, "|") != "Hel|lo|!" {
+		t.Errorf("unexpected chunks: %v", chunks)
+	}
+}
+
+// TestGenerate_APIKeyHeader verifies the bearer token is sent when configured.
+func TestGenerate_APIKeyHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL, APIKey: "sk-test", Model: "m", HTTP: &http.Client{}}
+	if _, err := client.Generate(context.Background(), "ping"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client.APIKey = ""
+	_, err := client.Generate(context.Background(), "ping")
+	if err == nil || !strings.Contains(err.Error(), "SSAGE_OPENAI_API_KEY") {
+		t.Errorf("expected an API key hint, got: %v", err)
+	}
+}
+
+// TestGenerate_NotFound verifies that a 404 returns a helpful error message.
+func TestGenerate_NotFound(t *testing.T) {
+	srv := newTestServer(t, "", http.StatusNotFound)
+	defer srv.Close()
+
+	client := &Client{BaseURL: srv.URL + "/v1", Model: "missing-model", HTTP: &http.Client{}}
+
+	_, err := client.Generate(context.Background(), "Explain ls")
+	if err == nil {
+		t.Fatal("expected an error for 404, got nil")
+	}
+	if !strings.Contains(err.Error(), "missing-model") {
+		t.Errorf("error should mention the model name, got: %s", err)
+	}
+}
+
+// TestNewClient_Env verifies base URL, API key and model resolution from env.
+func TestNewClient_Env(t *testing.T) {
+	t.Setenv("SSAGE_OPENAI_BASE_URL", "http://example.test:1234/v1/")
+	t.Setenv("SSAGE_OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "sk-fallback")
+	t.Setenv("SSAGE_MODEL", "env-model")
+
+	c := NewClient("")
+	if c.BaseURL != "http://example.test:1234/v1" {
+		t.Errorf("unexpected base URL: %q", c.BaseURL)
+	}
+	if c.APIKey != "sk-fallback" {
+		t.Errorf("expected OPENAI_API_KEY fallback, got %q", c.APIKey)
+	}
+	if c.Model != "env-model" {
+		t.Errorf("expected 'env-model', got %q", c.Model)
+	}
+	if c2 := NewClient("override-model"); c2.Model != "override-model" {
+		t.Errorf("expected 'override-model', got %q", c2.Model)
+	}
+}
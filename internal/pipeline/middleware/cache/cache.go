@@ -2,73 +2,84 @@
 // disk and replays them on subsequent identical requests, avoiding redundant
 // network calls to the AI backend.
 //
-// Cache entries are stored in ~/.ssage_cache/<sha256-of-prompt>.json and
-// expire after a configurable TTL. All disk operations fail silently so the
-// middleware degrades gracefully to a transparent pass-through when the
-// filesystem is unavailable.
+// Cache entries are stored in ~/.ssage_cache/<sha256-of-request>.json and
+// expire after a configurable TTL. The key covers the provider name, model,
+// generation options, system message, history and prompt, so switching
+// --model or --temperature never replays another configuration's answer.
+// All disk operations fail silently so the middleware degrades gracefully to
+// a transparent pass-through when the filesystem is unavailable.
+//
+// The directory is bounded by Limits: after every write the least recently
+// used entries (by last access time) are evicted until both the entry count
+// and total size are within bounds.
+//
+// Entries are written atomically (temp file + rename) so concurrent ssage
+// processes never observe partial JSON. Within a process, concurrent
+// identical requests are de-duplicated: the first one calls the provider and
+// the others wait for and share its response.
 //
 // Commands in the skip-list (e.g. "tip") always bypass the cache because
 // their prompt text is constant but the expected output should vary.
 package cache
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/provider"
 )
 
-// entry is the on-disk representation of a single cached response.
-type entry struct {
-	Response  string    `json:"response"`
-	CreatedAt time.Time `json:"created_at"`
-}
-
-// Middleware implements SHA256-keyed disk caching with a configurable TTL.
+// Middleware implements SHA256-keyed disk caching with a configurable TTL
+// and size-bounded LRU eviction.
 type Middleware struct {
-	dir      string
+	store    *Store
 	ttl      time.Duration
+	limits   Limits
 	skipCmds map[string]bool
+	flight   flightGroup
 }
 
-// New creates a cache Middleware.
+// New creates a cache Middleware backed by the default cache directory.
 //   - ttl controls how long entries remain valid. After expiry the entry is
 //     evicted on the next read and the provider is called again.
+//   - limits bounds the directory size; zero fields fall back to
+//     DefaultLimits.
 //   - skipCommands lists command names whose results must never be cached.
 //     Useful for commands like "tip" whose prompt is identical every run but
 //     whose output is expected to vary.
-func New(ttl time.Duration, skipCommands ...string) *Middleware {
+func New(ttl time.Duration, limits Limits, skipCommands ...string) *Middleware {
 	skip := make(map[string]bool, len(skipCommands))
 	for _, cmd := range skipCommands {
 		skip[cmd] = true
 	}
 
-	dir := cacheDir()
 	// Best-effort: if the directory can't be created, all cache operations
 	// become no-ops (reads return miss, writes are silently dropped).
-	_ = os.MkdirAll(dir, 0700)
-
-	return &Middleware{dir: dir, ttl: ttl, skipCmds: skip}
+	return &Middleware{store: Open(Dir()), ttl: ttl, limits: limits.WithDefaults(), skipCmds: skip}
 }
 
 // Wrap caches the response of non-streaming requests.
 func (m *Middleware) Wrap(next pipeline.Handler) pipeline.Handler {
-	return func(req pipeline.Request) (string, error) {
+	return func(ctx context.Context, req pipeline.Request) (string, error) {
 		if m.skipCmds[req.Command] {
-			return next(req)
+			return next(ctx, req)
 		}
-		key := hashKey(req.Prompt)
-		if cached, ok := m.load(key); ok {
+		key := hashKey(req)
+		if cached, ok := m.load(key, req.Command); ok {
+			req.Meta.Cached = true
 			return cached, nil
 		}
-		resp, err := next(req)
-		if err == nil {
-			m.store(key, resp)
-		}
+		resp, err, _ := m.flight.do(ctx, key, func() (string, error) {
+			resp, err := next(ctx, req)
+			if err == nil {
+				m.save(key, req, resp)
+			}
+			return resp, err
+		})
 		return resp, err
 	}
 }
@@ -76,66 +87,103 @@ func (m *Middleware) Wrap(next pipeline.Handler) pipeline.Handler {
 // WrapStream caches the full response of streaming requests. On a cache hit
 // the stored response is replayed as a single onChunk call so that command
 // output logic (spinner, box drawing, clipboard) behaves identically.
+// req.Meta.Cached is set before the replay so callers can label the output.
+// Requests that joined an identical in-flight request receive its response
+// as a single onChunk call once it completes.
 func (m *Middleware) WrapStream(next pipeline.StreamHandler) pipeline.StreamHandler {
-	return func(req pipeline.Request, onChunk func(string)) (string, error) {
+	return func(ctx context.Context, req pipeline.Request, onChunk func(string)) (string, error) {
 		if m.skipCmds[req.Command] {
-			return next(req, onChunk)
+			return next(ctx, req, onChunk)
 		}
-		key := hashKey(req.Prompt)
-		if cached, ok := m.load(key); ok {
+		key := hashKey(req)
+		if cached, ok := m.load(key, req.Command); ok {
+			req.Meta.Cached = true
 			onChunk(cached)
 			return cached, nil
 		}
-		resp, err := next(req, onChunk)
-		if err == nil {
-			m.store(key, resp)
+		resp, err, shared := m.flight.do(ctx, key, func() (string, error) {
+			resp, err := next(ctx, req, onChunk)
+			if err == nil {
+				m.save(key, req, resp)
+			}
+			return resp, err
+		})
+		if shared && err == nil {
+			onChunk(resp)
 		}
 		return resp, err
 	}
 }
 
-// hashKey returns the lowercase hex SHA-256 of the prompt, used as the
-// cache filename (without extension).
-func hashKey(prompt string) string {
-	sum := sha256.Sum256([]byte(prompt))
-	return hex.EncodeToString(sum[:])
+// keyFields is the canonical set of request fields that identify a cached
+// response. It is JSON-encoded and hashed, so field order is stable.
+type keyFields struct {
+	Provider string             `json:"provider"`
+	Endpoint string             `json:"endpoint,omitempty"`
+	Model    string             `json:"model"`
+	Options  provider.Options   `json:"options"`
+	System   string             `json:"system"`
+	History  []provider.Message `json:"history,omitempty"`
+	Prompt   string             `json:"prompt"`
 }
 
-// cacheDir returns the path to the cache directory.
-func cacheDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return filepath.Join(os.TempDir(), ".ssage_cache")
-	}
-	return filepath.Join(home, ".ssage_cache")
+// hashKey returns the lowercase hex SHA-256 of the request's identifying
+// fields, used as the cache filename (without extension). KeepAlive is left
+// out: how long the model stays loaded does not change the answer.
+func hashKey(req pipeline.Request) string {
+	opts := req.Options
+	opts.KeepAlive = ""
+	data, _ := json.Marshal(keyFields{
+		Provider: req.Provider,
+		Endpoint: req.Endpoint,
+		Model:    req.Model,
+		Options:  opts,
+		System:   req.System,
+		History:  req.History,
+		Prompt:   req.Prompt,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
-// load reads and validates a cache entry.
+// load reads and validates a cache entry, refreshing its access time on a
+// hit and recording the hit or miss for `ssage cache stats`.
 // Returns ("", false) on any failure (missing file, JSON error, TTL expired).
-func (m *Middleware) load(key string) (string, bool) {
-	path := filepath.Join(m.dir, key+".json")
-	data, err := os.ReadFile(path)
+func (m *Middleware) load(key, command string) (string, bool) {
+	e, err := m.store.Get(key)
 	if err != nil {
-		return "", false
-	}
-	var e entry
-	if err := json.Unmarshal(data, &e); err != nil {
+		m.store.countLookup(command, false)
 		return "", false
 	}
 	if time.Since(e.CreatedAt) > m.ttl {
-		_ = os.Remove(path) // evict expired entry
+		_ = m.store.Remove(key) // evict expired entry
+		m.store.countLookup(command, false)
 		return "", false
 	}
+	e.AccessedAt = time.Now()
+	e.Hits++
+	_ = m.store.Put(e)
+	m.store.countLookup(command, true)
 	return e.Response, true
 }
 
-// store writes a response entry to disk. Errors are silently dropped.
-func (m *Middleware) store(key, response string) {
-	e := entry{Response: response, CreatedAt: time.Now()}
-	data, err := json.MarshalIndent(e, "", "  ")
-	if err != nil {
+// save writes a response entry to disk and enforces the size limits.
+// Errors are silently dropped.
+func (m *Middleware) save(key string, req pipeline.Request, response string) {
+	now := time.Now()
+	e := &Entry{
+		Key:        key,
+		Provider:   req.Provider,
+		Model:      req.Model,
+		Options:    req.Options,
+		Command:    req.Command,
+		Prompt:     req.Prompt,
+		Response:   response,
+		CreatedAt:  now,
+		AccessedAt: now,
+	}
+	if err := m.store.Put(e); err != nil {
 		return
 	}
-	path := filepath.Join(m.dir, key+".json")
-	_ = os.WriteFile(path, data, 0600)
+	_, _, _ = m.store.Evict(m.limits)
 }
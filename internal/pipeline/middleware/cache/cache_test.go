@@ -0,0 +1,259 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/provider"
+)
+
+// newTestCache returns a Middleware backed by a temporary directory.
+func newTestCache(t *testing.T) *Middleware {
+	t.Helper()
+	return &Middleware{store: Open(t.TempDir()), ttl: time.Hour, limits: DefaultLimits, skipCmds: map[string]bool{}}
+}
+
+// countingHandler returns a StreamHandler that answers with the model name
+// and counts how many times the backend was reached.
+func countingHandler(calls *int) pipeline.StreamHandler {
+	return func(ctx context.Context, req pipeline.Request, onChunk func(string)) (string, error) {
+		*calls++
+		onChunk(req.Model)
+		return req.Model, nil
+	}
+}
+
+// TestWrapStream_KeyIncludesModelAndOptions verifies that a different model,
+// endpoint or generation option is a cache miss, while an identical request,
+// or one that differs only in KeepAlive, is a hit.
+func TestWrapStream_KeyIncludesModelAndOptions(t *testing.T) {
+	m := newTestCache(t)
+	calls := 0
+	h := m.WrapStream(countingHandler(&calls))
+	ctx := context.Background()
+
+	endpoint := "http://localhost:8080/v1"
+	run := func(model string, opts provider.Options) (string, bool) {
+		req := pipeline.Request{Provider: "openai", Endpoint: endpoint, Model: model, Prompt: "explain ls", Options: opts, Meta: &pipeline.Meta{}}
+		resp, err := h(ctx, req, func(string) {})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp, req.Meta.Cached
+	}
+
+	if resp, cached := run("llama3", provider.Options{}); resp != "llama3" || cached {
+		t.Fatalf("first run: got %q cached=%v", resp, cached)
+	}
+	if resp, cached := run("llama3", provider.Options{}); resp != "llama3" || !cached {
+		t.Fatalf("identical run should hit: got %q cached=%v", resp, cached)
+	}
+	if resp, cached := run("mistral", provider.Options{}); resp != "mistral" || cached {
+		t.Fatalf("different model must miss: got %q cached=%v", resp, cached)
+	}
+	temp := 0.0
+	if _, cached := run("llama3", provider.Options{Temperature: &temp}); cached {
+		t.Fatal("different options must miss")
+	}
+	if _, cached := run("llama3", provider.Options{KeepAlive: "30m"}); !cached {
+		t.Fatal("a different keep-alive should still hit")
+	}
+	endpoint = "http://gpu-box:8000/v1"
+	if _, cached := run("llama3", provider.Options{}); cached {
+		t.Fatal("a different endpoint must miss")
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 backend calls, got %d", calls)
+	}
+}
+
+// TestEvict_LRU verifies that eviction removes the least recently accessed
+// entries first and that a cache hit refreshes an entry's recency.
+func TestEvict_LRU(t *testing.T) {
+	m := newTestCache(t)
+	m.limits = Limits{MaxEntries: 2, MaxBytes: DefaultLimits.MaxBytes}
+	calls := 0
+	h := m.WrapStream(countingHandler(&calls))
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	keys := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		req := pipeline.Request{Model: fmt.Sprintf("m%d", i), Prompt: "p", Meta: &pipeline.Meta{}}
+		if _, err := h(ctx, req, func(string) {}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		key := hashKey(req)
+		keys = append(keys, key)
+		// Spread access times deterministically: m0 oldest, m2 newest.
+		ts := base.Add(time.Duration(i) * time.Minute)
+		_ = os.Chtimes(m.store.path(key), ts, ts)
+		if i == 1 {
+			// Touch m0 so that m1 becomes the least recently used.
+			if _, err := h(ctx, pipeline.Request{Model: "m0", Prompt: "p", Meta: &pipeline.Meta{}}, func(string) {}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	entries, err := m.store.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries after eviction, got %d", len(entries))
+	}
+	if _, err := m.store.Get(keys[1]); err == nil {
+		t.Error("expected least recently used entry m1 to be evicted")
+	}
+	if _, err := m.store.Get(keys[0]); err != nil {
+		t.Error("expected recently hit entry m0 to survive")
+	}
+
+	stats, err := m.store.Stats()
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if c := stats[""].Counter; c.Hits != 1 || c.Misses != 3 {
+		t.Errorf("unexpected counters: %+v", c)
+	}
+}
+
+// TestWrapStream_SingleFlight verifies that concurrent identical requests
+// share one provider call and all receive the full response.
+func TestWrapStream_SingleFlight(t *testing.T) {
+	const n = 16
+	m := newTestCache(t)
+	joined := make(chan struct{}, n)
+	m.flight.joined = func(string) { joined <- struct{}{} }
+	var calls atomic.Int32
+	release := make(chan struct{})
+	h := m.WrapStream(func(ctx context.Context, req pipeline.Request, onChunk func(string)) (string, error) {
+		calls.Add(1)
+		<-release
+		onChunk("hello")
+		return "hello", nil
+	})
+
+	var wg sync.WaitGroup
+	results := make([]string, n)
+	streamed := make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := pipeline.Request{Model: "m", Prompt: "same", Meta: &pipeline.Meta{}}
+			resp, err := h(context.Background(), req, func(tok string) { streamed[i] += tok })
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results[i] = resp
+		}(i)
+	}
+	// The provider call blocks until every other caller has joined it.
+	for i := 0; i < n-1; i++ {
+		<-joined
+	}
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected exactly 1 provider call, got %d", got)
+	}
+	for i := range results {
+		if results[i] != "hello" || streamed[i] != "hello" {
+			t.Errorf("caller %d: resp=%q streamed=%q", i, results[i], streamed[i])
+		}
+	}
+}
+
+// TestFlight_LeaderCancelled verifies that a waiter is not failed by the
+// leader's cancellation and performs its own call instead.
+func TestFlight_LeaderCancelled(t *testing.T) {
+	joined := make(chan struct{}, 1)
+	g := flightGroup{joined: func(string) { joined <- struct{}{} }}
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	go func() {
+		_, _, _ = g.do(leaderCtx, "k", func() (string, error) {
+			close(started)
+			<-leaderCtx.Done()
+			return "", leaderCtx.Err()
+		})
+	}()
+	<-started
+
+	done := make(chan struct{})
+	var resp string
+	var err error
+	var runs int
+	go func() {
+		defer close(done)
+		resp, err, _ = g.do(context.Background(), "k", func() (string, error) {
+			runs++
+			return "mine", nil
+		})
+	}()
+	<-joined
+	cancel()
+	<-done
+
+	if err != nil || resp != "mine" {
+		t.Errorf("expected waiter to retry and get %q, got %q, %v", "mine", resp, err)
+	}
+	if runs != 1 {
+		t.Errorf("waiter's call ran %d times, want 1", runs)
+	}
+}
+
+// TestStore_ConcurrentPut verifies that concurrent writers and readers of
+// the same key never observe a partially written entry.
+func TestStore_ConcurrentPut(t *testing.T) {
+	s := Open(t.TempDir())
+	key := hashKey(pipeline.Request{Prompt: "race"})
+	big := strings.Repeat("x", 64<<10)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				_ = s.Put(&Entry{Key: key, Response: fmt.Sprintf("%d-%s", w, big)})
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				e, err := s.Get(key)
+				if err != nil {
+					if os.IsNotExist(err) {
+						continue
+					}
+					t.Errorf("read observed a corrupt entry: %v", err)
+					return
+				}
+				if !strings.HasSuffix(e.Response, big) {
+					t.Errorf("read observed a truncated response (%d bytes)", len(e.Response))
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	files, _ := os.ReadDir(s.Path())
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".tmp-") {
+			t.Errorf("leftover temp file %s", f.Name())
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// call is an in-flight or completed provider call shared by all callers
+// that asked for the same key while it was running.
+type call struct {
+	done chan struct{}
+	resp string
+	err  error
+}
+
+// flightGroup de-duplicates concurrent calls with the same key so that
+// identical requests running through one Pipeline reach the provider once.
+// It is a minimal, context-aware variant of x/sync/singleflight.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+
+	// joined, if set, is called when a caller starts waiting for an
+	// in-flight call. Tests use it to know the caller has joined.
+	joined func(key string)
+}
+
+// do runs fn for key unless a call for key is already in flight, in which
+// case it waits for that call and returns its result with shared == true.
+//
+// A waiting caller stops waiting when its own ctx is cancelled. If the
+// leader's call failed only because the leader was cancelled, a waiter whose
+// ctx is still live retries instead of inheriting the cancellation.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (string, error)) (resp string, err error, shared bool) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*call)
+		}
+		if c, ok := g.calls[key]; ok {
+			g.mu.Unlock()
+			if g.joined != nil {
+				g.joined(key)
+			}
+			select {
+			case <-c.done:
+			case <-ctx.Done():
+				return "", ctx.Err(), true
+			}
+			if isContextErr(c.err) && ctx.Err() == nil {
+				continue // the leader was cancelled, not us: try again
+			}
+			return c.resp, c.err, true
+		}
+		c := &call{done: make(chan struct{})}
+		g.calls[key] = c
+		g.mu.Unlock()
+
+		// Always release waiters, even if fn panics.
+		defer func() {
+			g.mu.Lock()
+			delete(g.calls, key)
+			g.mu.Unlock()
+			close(c.done)
+		}()
+		c.resp, c.err = fn()
+		return c.resp, c.err, false
+	}
+}
+
+func isContextErr(err error) bool {
+	return err == context.Canceled || err == context.DeadlineExceeded
+}
@@ -0,0 +1,384 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shell-sage/internal/provider"
+)
+
+// countersFile holds the per-command hit/miss counters. Its name can never
+// collide with an entry file, which is always <64 hex chars>.json.
+const countersFile = "counters.json"
+
+// Entry is the on-disk representation of a single cached response. The
+// request fields are recorded alongside the response so entries can be
+// inspected and attributed to the configuration that produced them.
+type Entry struct {
+	Key        string           `json:"key"`
+	Provider   string           `json:"provider"`
+	Model      string           `json:"model"`
+	Options    provider.Options `json:"options"`
+	Command    string           `json:"command"`
+	Prompt     string           `json:"prompt"`
+	Response   string           `json:"response"`
+	CreatedAt  time.Time        `json:"created_at"`
+	AccessedAt time.Time        `json:"accessed_at"`
+	Hits       int              `json:"hits"`
+
+	// Size is the size of the entry file in bytes. It is filled in by List
+	// and Get and is not persisted.
+	Size int64 `json:"-"`
+}
+
+// Limits bounds the cache directory. Zero fields fall back to DefaultLimits.
+type Limits struct {
+	MaxEntries int
+	MaxBytes   int64
+}
+
+// DefaultLimits keeps the cache small enough to never be noticed on disk.
+var DefaultLimits = Limits{MaxEntries: 1000, MaxBytes: 50 << 20}
+
+// WithDefaults returns l with zero fields replaced by DefaultLimits.
+func (l Limits) WithDefaults() Limits {
+	if l.MaxEntries <= 0 {
+		l.MaxEntries = DefaultLimits.MaxEntries
+	}
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = DefaultLimits.MaxBytes
+	}
+	return l
+}
+
+// Counter tracks cache lookups for one command.
+type Counter struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// CommandStats summarizes the cache for one command.
+type CommandStats struct {
+	Entries int
+	Bytes   int64
+	Counter
+	Oldest time.Time
+	Newest time.Time
+}
+
+// Store is the cache directory shared by the middleware and the
+// `ssage cache` command. Entry files are named <key>.json and their
+// modification time doubles as the last access time for LRU eviction.
+type Store struct {
+	dir string
+
+	// mu serializes read-modify-write updates of the counters file within
+	// this process.
+	mu sync.Mutex
+}
+
+// Dir returns the path to the default cache directory.
+func Dir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), ".ssage_cache")
+	}
+	return filepath.Join(home, ".ssage_cache")
+}
+
+// Open returns a Store rooted at dir, creating the directory if needed.
+func Open(dir string) *Store {
+	_ = os.MkdirAll(dir, 0700)
+	return &Store{dir: dir}
+}
+
+// Path returns the directory backing the store.
+func (s *Store) Path() string { return s.dir }
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// isKey reports whether name is an entry file name (<64 hex chars>.json).
+func isKey(name string) bool {
+	key, ok := strings.CutSuffix(name, ".json")
+	if !ok || len(key) != 64 {
+		return false
+	}
+	for _, c := range key {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get reads the entry stored under key.
+func (s *Store) Get(key string) (*Entry, error) {
+	path := s.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("corrupt cache entry %s: %w", key, err)
+	}
+	e.Key = key
+	e.Size = int64(len(data))
+	return &e, nil
+}
+
+// Put atomically writes e under e.Key, replacing any previous entry.
+func (s *Store) Put(e *Entry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(s.path(e.Key), data)
+}
+
+// writeAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers see either the old or the new content
+// but never a partial write. Temp files start with "." and are ignored by
+// files().
+func writeAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Remove deletes the entry stored under key.
+func (s *Store) Remove(key string) error {
+	return os.Remove(s.path(key))
+}
+
+// Resolve expands a unique key prefix (as printed by `ssage cache list`)
+// into the full key.
+func (s *Store) Resolve(prefix string) (string, error) {
+	files, err := s.files()
+	if err != nil {
+		return "", err
+	}
+	var match string
+	for _, f := range files {
+		key := strings.TrimSuffix(f.Name(), ".json")
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if match != "" {
+			return "", fmt.Errorf("key prefix %q is ambiguous", prefix)
+		}
+		match = key
+	}
+	if match == "" {
+		return "", fmt.Errorf("no cache entry matches %q", prefix)
+	}
+	return match, nil
+}
+
+// List reads every entry, most recently used first. Unreadable entries are
+// skipped.
+func (s *Store) List() ([]*Entry, error) {
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*Entry, 0, len(files))
+	for _, f := range files {
+		e, err := s.Get(strings.TrimSuffix(f.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].AccessedAt.After(entries[j].AccessedAt)
+	})
+	return entries, nil
+}
+
+// Clear removes every entry and resets the hit/miss counters.
+// It returns the number of entries removed.
+func (s *Store) Clear() (int, error) {
+	files, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, f := range files {
+		if err := os.Remove(filepath.Join(s.dir, f.Name())); err == nil {
+			removed++
+		}
+	}
+	_ = os.Remove(filepath.Join(s.dir, countersFile))
+	return removed, nil
+}
+
+// Prune removes expired entries (older than ttl) and then evicts least
+// recently used entries until limits are satisfied. It returns the number
+// of entries removed and the bytes freed.
+func (s *Store) Prune(ttl time.Duration, limits Limits) (int, int64, error) {
+	entries, err := s.List()
+	if err != nil {
+		return 0, 0, err
+	}
+	removed, freed := 0, int64(0)
+	for _, e := range entries {
+		if time.Since(e.CreatedAt) > ttl {
+			if err := s.Remove(e.Key); err == nil {
+				removed++
+				freed += e.Size
+			}
+		}
+	}
+	n, b, err := s.Evict(limits)
+	return removed + n, freed + b, err
+}
+
+// Evict removes least recently used entries until the store holds at most
+// limits.MaxEntries entries and limits.MaxBytes bytes. Recency comes from
+// file modification times, so no entry needs to be parsed.
+func (s *Store) Evict(limits Limits) (int, int64, error) {
+	limits = limits.WithDefaults()
+	files, err := s.files()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	type file struct {
+		name  string
+		size  int64
+		mtime time.Time
+	}
+	list := make([]file, 0, len(files))
+	var total int64
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, file{f.Name(), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	// Oldest access first: these are evicted first.
+	sort.Slice(list, func(i, j int) bool { return list[i].mtime.Before(list[j].mtime) })
+
+	removed, freed := 0, int64(0)
+	for _, f := range list {
+		if len(list)-removed <= limits.MaxEntries && total-freed <= limits.MaxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(s.dir, f.name)); err != nil {
+			continue
+		}
+		removed++
+		freed += f.size
+	}
+	return removed, freed, nil
+}
+
+// Stats summarizes the store per command, combining the entries on disk with
+// the persisted hit/miss counters.
+func (s *Store) Stats() (map[string]*CommandStats, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]*CommandStats)
+	get := func(cmd string) *CommandStats {
+		if stats[cmd] == nil {
+			stats[cmd] = &CommandStats{}
+		}
+		return stats[cmd]
+	}
+	for _, e := range entries {
+		cs := get(e.Command)
+		cs.Entries++
+		cs.Bytes += e.Size
+		if cs.Oldest.IsZero() || e.CreatedAt.Before(cs.Oldest) {
+			cs.Oldest = e.CreatedAt
+		}
+		if e.CreatedAt.After(cs.Newest) {
+			cs.Newest = e.CreatedAt
+		}
+	}
+	for cmd, c := range s.counters() {
+		get(cmd).Counter = c
+	}
+	return stats, nil
+}
+
+// files returns the directory entries that are cache entry files.
+func (s *Store) files() ([]os.DirEntry, error) {
+	all, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	files := all[:0]
+	for _, f := range all {
+		if f.Type().IsRegular() && isKey(f.Name()) {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// counters loads the per-command hit/miss counters.
+func (s *Store) counters() map[string]Counter {
+	c := make(map[string]Counter)
+	data, err := os.ReadFile(filepath.Join(s.dir, countersFile))
+	if err != nil {
+		return c
+	}
+	_ = json.Unmarshal(data, &c)
+	return c
+}
+
+// countLookup records a cache hit or miss for command. Best-effort.
+func (s *Store) countLookup(command string, hit bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.counters()
+	cur := c[command]
+	if hit {
+		cur.Hits++
+	} else {
+		cur.Misses++
+	}
+	c[command] = cur
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	_ = writeAtomic(filepath.Join(s.dir, countersFile), data)
+}
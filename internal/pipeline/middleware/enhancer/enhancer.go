@@ -1,5 +1,6 @@
-// Package enhancer provides a pipeline.Middleware that prepends a system
-// context block to every prompt before it reaches the AI backend.
+// Package enhancer provides a pipeline.Middleware that appends a system
+// context block to every request's system message before it reaches the AI
+// backend.
 //
 // The injected block looks like:
 //
@@ -10,6 +11,7 @@
 package enhancer
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -25,31 +27,34 @@ func New() *Middleware {
 	return &Middleware{}
 }
 
-// Wrap prepends system context to the prompt before calling next.
+// Wrap adds system context to the system message before calling next.
 func (m *Middleware) Wrap(next pipeline.Handler) pipeline.Handler {
-	return func(req pipeline.Request) (string, error) {
-		req.Prompt = inject(req.Prompt)
-		return next(req)
+	return func(ctx context.Context, req pipeline.Request) (string, error) {
+		req.System = inject(req.System)
+		return next(ctx, req)
 	}
 }
 
-// WrapStream prepends system context to the prompt before calling next.
+// WrapStream adds system context to the system message before calling next.
 func (m *Middleware) WrapStream(next pipeline.StreamHandler) pipeline.StreamHandler {
-	return func(req pipeline.Request, onChunk func(string)) (string, error) {
-		req.Prompt = inject(req.Prompt)
-		return next(req, onChunk)
+	return func(ctx context.Context, req pipeline.Request, onChunk func(string)) (string, error) {
+		req.System = inject(req.System)
+		return next(ctx, req, onChunk)
 	}
 }
 
-// inject builds and prepends the context prefix string.
-func inject(prompt string) string {
+// inject builds the context line and appends it to the system message.
+func inject(system string) string {
 	shell := os.Getenv("SHELL")
 	if shell == "" {
 		shell = "unknown"
 	}
-	prefix := fmt.Sprintf(
-		"[System context: OS=%s, Arch=%s, Shell=%s]\n",
+	line := fmt.Sprintf(
+		"[System context: OS=%s, Arch=%s, Shell=%s]",
 		runtime.GOOS, runtime.GOARCH, shell,
 	)
-	return prefix + prompt
+	if system == "" {
+		return line
+	}
+	return system + "\n" + line
 }
@@ -0,0 +1,216 @@
+// Package redact provides a pipeline.Middleware that masks secrets in the
+// prompt before it leaves the process.
+//
+// It runs ahead of the cache so that neither the provider, ~/.ssage_cache
+// nor ~/.ssage.log ever see the original values. Detected items are
+// replaced with a "[REDACTED:<kind>]" marker and counted in
+// req.Meta.Redacted so commands can tell the user what happened.
+//
+// Built-in rules cover cloud and SaaS API keys, JWTs, bearer and basic
+// authorization headers, credentials embedded in URLs, private key blocks,
+// secret-looking variable assignments and high-entropy strings. Extra
+// regular expressions can be supplied by the user; for those the whole
+// match is masked unless the expression has a capture group, in which case
+// only the first group is masked.
+package redact
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/shell-sage/internal/pipeline"
+	"github.com/shell-sage/internal/provider"
+)
+
+// rule is a single detection pattern. When group is > 0 only that capture
+// group is replaced, preserving surrounding context such as variable names
+// or header keys.
+type rule struct {
+	kind  string
+	re    *regexp.Regexp
+	group int
+}
+
+// builtin rules are applied in order; more specific rules come first so the
+// generic ones see already-masked text.
+var builtin = []rule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`), 0},
+	{"aws-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), 0},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), 0},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), 0},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`), 0},
+	{"api-key", regexp.MustCompile(`\b(?:sk|rk|pk)[-_](?:live|test|proj|ant)?[-_]?[A-Za-z0-9_\-]{20,}`), 0},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), 0},
+	{"bearer-token", regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/\-]+=*)`), 1},
+	{"basic-auth", regexp.MustCompile(`(?i)\bauthorization:\s*basic\s+([A-Za-z0-9+/]+=*)`), 1},
+	{"url-credentials", regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.\-]*://[^/\s:@]+:([^@\s/]+)@`), 1},
+	{"secret-assignment", regexp.MustCompile(`(?i)\b[A-Z0-9_.\-]*(?:secret|token|passw(?:or)?d|pwd|api[_\-]?key|access[_\-]?key|private[_\-]?key|credentials?)[A-Z0-9_.\-]*["']?\s*[=:]\s*("[^"\n]+"|'[^'\n]+'|[^\s"'|;&]+)`), 1},
+	{"secret-flag", regexp.MustCompile(`(?i)--(?:password|passwd|token|secret|api-key)(?:=|\s+)("[^"\n]+"|'[^'\n]+'|\S+)`), 1},
+}
+
+// highEntropyCandidate matches long opaque tokens; they are masked only when
+// their Shannon entropy suggests random data (see isHighEntropy).
+var highEntropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_\-]{32,}={0,2}`)
+
+// Middleware masks secrets in requests before they reach the next handler.
+type Middleware struct {
+	rules []rule
+}
+
+// New creates a redaction Middleware with the built-in rules plus the given
+// extra regular expressions (e.g. from the "redact.patterns" config key).
+// It returns an error if any extra pattern does not compile.
+func New(extraPatterns ...string) (*Middleware, error) {
+	rules := append([]rule(nil), builtin...)
+	for _, p := range extraPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		group := 0
+		if re.NumSubexp() > 0 {
+			group = 1
+		}
+		rules = append(rules, rule{kind: "custom", re: re, group: group})
+	}
+	return &Middleware{rules: rules}, nil
+}
+
+// Wrap masks secrets in the prompt and history before calling next.
+func (m *Middleware) Wrap(next pipeline.Handler) pipeline.Handler {
+	return func(ctx context.Context, req pipeline.Request) (string, error) {
+		return next(ctx, m.apply(req))
+	}
+}
+
+// WrapStream masks secrets in the prompt and history before calling next.
+func (m *Middleware) WrapStream(next pipeline.StreamHandler) pipeline.StreamHandler {
+	return func(ctx context.Context, req pipeline.Request, onChunk func(string)) (string, error) {
+		return next(ctx, m.apply(req), onChunk)
+	}
+}
+
+// apply redacts the user-supplied parts of req and records the count.
+func (m *Middleware) apply(req pipeline.Request) pipeline.Request {
+	var total, n int
+	req.Prompt, n = m.Redact(req.Prompt)
+	total += n
+	if len(req.History) > 0 {
+		history := make([]provider.Message, len(req.History))
+		copy(history, req.History)
+		for i := range history {
+			history[i].Content, n = m.Redact(history[i].Content)
+			total += n
+		}
+		req.History = history
+	}
+	if req.Meta != nil {
+		req.Meta.Redacted += total
+	}
+	return req
+}
+
+// Redact masks every secret found in s and returns the result together with
+// the number of items masked.
+func (m *Middleware) Redact(s string) (string, int) {
+	count := 0
+	for _, r := range m.rules {
+		s = replace(r, s, &count)
+	}
+	s = highEntropyCandidate.ReplaceAllStringFunc(s, func(tok string) string {
+		if !isHighEntropy(tok) {
+			return tok
+		}
+		count++
+		return marker("high-entropy")
+	})
+	return s, count
+}
+
+// String masks secrets in s using only the built-in rules. It is meant for
+// sanitizing text written to the local log file.
+func String(s string) string {
+	out, _ := defaultMiddleware.Redact(s)
+	return out
+}
+
+var defaultMiddleware = &Middleware{rules: builtin}
+
+func marker(kind string) string {
+	return "[REDACTED:" + kind + "]"
+}
+
+// replace applies a single rule to s, incrementing count per masked item.
+func replace(r rule, s string, count *int) string {
+	if r.group == 0 {
+		return r.re.ReplaceAllStringFunc(s, func(match string) string {
+			if strings.HasPrefix(match, "[REDACTED:") {
+				return match
+			}
+			*count++
+			return marker(r.kind)
+		})
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range r.re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := loc[2*r.group], loc[2*r.group+1]
+		if start < 0 || strings.HasPrefix(s[start:end], "[REDACTED:") {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(marker(r.kind))
+		last = end
+		*count++
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// isHighEntropy reports whether tok looks like random key material rather
+// than a word, path or hash. Pure hex strings (commit SHAs, checksums) are
+// left alone because they are common in shell output and rarely secret.
+func isHighEntropy(tok string) bool {
+	hasUpper, hasLower, hasDigit, hexOnly := false, false, false, true
+	for _, c := range tok {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			hasUpper = true
+			if c > 'F' {
+				hexOnly = false
+			}
+		case c >= 'a' && c <= 'z':
+			hasLower = true
+			if c > 'f' {
+				hexOnly = false
+			}
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		default:
+			hexOnly = false
+		}
+	}
+	if hexOnly || !hasDigit || !(hasUpper && hasLower) {
+		return false
+	}
+	return entropy(tok) >= 4.0
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	freq := make(map[rune]int)
+	for _, c := range s {
+		freq[c]++
+	}
+	n := float64(len(s))
+	var h float64
+	for _, f := range freq {
+		p := float64(f) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -7,9 +7,13 @@
 // delivered to the caller yet. Once the first token has been sent, the caller
 // has already begun rendering output and a retry would produce duplicate
 // content, so the error is returned as-is.
+//
+// Cancellation of the request context is never retried: backoff sleeps are
+// interrupted immediately and ctx.Err() is returned.
 package retry
 
 import (
+	"context"
 	"time"
 
 	"github.com/shell-sage/internal/pipeline"
@@ -34,19 +38,24 @@ func New(maxAttempts int) *Middleware {
 // Wrap retries the non-streaming handler on any error, sleeping between
 // attempts using exponential backoff (500 ms, 1 s, 2 s, …).
 func (m *Middleware) Wrap(next pipeline.Handler) pipeline.Handler {
-	return func(req pipeline.Request) (string, error) {
+	return func(ctx context.Context, req pipeline.Request) (string, error) {
 		var (
 			resp string
 			err  error
 		)
 		for attempt := 0; attempt < m.maxAttempts; attempt++ {
 			if attempt > 0 {
-				time.Sleep(backoff(attempt - 1))
+				if serr := sleep(ctx, backoff(attempt-1)); serr != nil {
+					return resp, serr
+				}
 			}
-			resp, err = next(req)
+			resp, err = next(ctx, req)
 			if err == nil {
 				return resp, nil
 			}
+			if ctx.Err() != nil {
+				return resp, ctx.Err()
+			}
 		}
 		return resp, err
 	}
@@ -56,7 +65,7 @@ func (m *Middleware) Wrap(next pipeline.Handler) pipeline.Handler {
 // received yet. Once the first token arrives, the caller has started rendering
 // so we cannot restart the stream without corrupting the output.
 func (m *Middleware) WrapStream(next pipeline.StreamHandler) pipeline.StreamHandler {
-	return func(req pipeline.Request, onChunk func(string)) (string, error) {
+	return func(ctx context.Context, req pipeline.Request, onChunk func(string)) (string, error) {
 		var (
 			resp     string
 			err      error
@@ -64,7 +73,9 @@ func (m *Middleware) WrapStream(next pipeline.StreamHandler) pipeline.StreamHand
 		)
 		for attempt := 0; attempt < m.maxAttempts; attempt++ {
 			if attempt > 0 {
-				time.Sleep(backoff(attempt - 1))
+				if serr := sleep(ctx, backoff(attempt-1)); serr != nil {
+					return resp, serr
+				}
 			}
 			received = false
 
@@ -74,10 +85,13 @@ func (m *Middleware) WrapStream(next pipeline.StreamHandler) pipeline.StreamHand
 				onChunk(token)
 			}
 
-			resp, err = next(req, guarded)
+			resp, err = next(ctx, req, guarded)
 			if err == nil {
 				return resp, nil
 			}
+			if ctx.Err() != nil {
+				return resp, ctx.Err()
+			}
 			if received {
 				// Tokens already sent downstream — cannot retry cleanly.
 				return resp, err
@@ -96,3 +110,16 @@ func backoff(attempt int) time.Duration {
 	}
 	return d
 }
+
+// sleep waits for d or until ctx is cancelled, whichever happens first.
+// It returns ctx.Err() if the wait was interrupted.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -10,32 +10,73 @@
 //	    cache.New(24*time.Hour, "tip"),
 //	    retry.New(3),
 //	)
-//	response, err := pipe.RunStream(prompt, "explain", func(token string) {
+//	req := pipeline.Request{System: "You are a shell expert.", Prompt: prompt, Command: "explain"}
+//	response, err := pipe.RunStream(ctx, req, func(token string) {
 //	    fmt.Print(token)
 //	})
 package pipeline
 
-import "github.com/shell-sage/internal/provider"
+import (
+	"context"
+
+	"github.com/shell-sage/internal/provider"
+)
 
 // Request carries the prompt and metadata through the middleware chain.
 type Request struct {
-	// Prompt is the full text sent to the AI backend. Middlewares (e.g.
-	// enhancer) may modify it before it reaches the provider.
+	// System holds the role and response-format instructions sent to the
+	// backend as the system message. Middlewares (e.g. enhancer) may extend
+	// it before it reaches the provider.
+	System string
+
+	// History holds earlier user/assistant turns of the conversation, oldest
+	// first. It is empty for one-shot commands.
+	History []provider.Message
+
+	// Prompt is the user's current message, sent after History.
 	Prompt string
 
 	// Command is the ssage command name that initiated this request
 	// (e.g. "explain", "fix", "analyze", "tip"). Middlewares may use it
 	// to apply command-specific logic (e.g. cache skip-lists).
 	Command string
+
+	// Options holds generation parameters (temperature, seed, …) forwarded
+	// to the provider unchanged.
+	Options provider.Options
+
+	// Provider, Endpoint and Model identify the backend serving the
+	// request. They are filled in by Pipeline.Run/RunStream; middlewares
+	// must not modify them. Endpoint is empty for providers that do not
+	// implement provider.Endpointer.
+	Provider string
+	Endpoint string
+	Model    string
+
+	// Meta collects information middlewares report back to the caller (e.g.
+	// whether the answer came from the cache). Run/RunStream allocate it when
+	// nil; callers that want to inspect it should set it themselves.
+	Meta *Meta
+}
+
+// Meta describes how a request was served.
+type Meta struct {
+	// Cached is true when the response was replayed from the cache rather
+	// than generated by the model.
+	Cached bool
+
+	// Redacted counts the secrets masked in the request before it was sent.
+	Redacted int
 }
 
 // Handler is the function type for non-streaming invocations.
-// Each middleware wraps the next Handler in the chain.
-type Handler func(req Request) (string, error)
+// Each middleware wraps the next Handler in the chain. ctx carries the
+// cancellation signal of the originating command and must be forwarded.
+type Handler func(ctx context.Context, req Request) (string, error)
 
 // StreamHandler is the function type for streaming invocations.
 // onChunk is called once per token received from the backend.
-type StreamHandler func(req Request, onChunk func(string)) (string, error)
+type StreamHandler func(ctx context.Context, req Request, onChunk func(string)) (string, error)
 
 // Middleware adds cross-cutting behavior around a Handler and StreamHandler.
 // Implementations must be stateless (or thread-safe) as a single instance is
@@ -53,6 +94,7 @@ type Middleware interface {
 // The first middleware in the slice is the outermost layer (runs first on
 // ingress, last on egress). This is the standard onion/Russian-doll model.
 type Pipeline struct {
+	provider      provider.Provider
 	handler       Handler
 	streamHandler StreamHandler
 }
@@ -61,11 +103,11 @@ type Pipeline struct {
 // Calling New with no middlewares creates a direct pass-through to the provider.
 func New(p provider.Provider, middlewares ...Middleware) *Pipeline {
 	// Terminal handlers that delegate directly to the provider.
-	baseH := Handler(func(req Request) (string, error) {
-		return p.Generate(req.Prompt)
+	baseH := Handler(func(ctx context.Context, req Request) (string, error) {
+		return p.Chat(ctx, req.chat())
 	})
-	baseSH := StreamHandler(func(req Request, onChunk func(string)) (string, error) {
-		return p.GenerateStream(req.Prompt, onChunk)
+	baseSH := StreamHandler(func(ctx context.Context, req Request, onChunk func(string)) (string, error) {
+		return p.ChatStream(ctx, req.chat(), onChunk)
 	})
 
 	// Wrap from last to first so that middlewares[0] is outermost.
@@ -76,17 +118,40 @@ func New(p provider.Provider, middlewares ...Middleware) *Pipeline {
 		sh = middlewares[i].WrapStream(sh)
 	}
 
-	return &Pipeline{handler: h, streamHandler: sh}
+	return &Pipeline{provider: p, handler: h, streamHandler: sh}
 }
 
 // Run executes the full middleware chain for a non-streaming request and
-// returns the complete response.
-func (p *Pipeline) Run(prompt, command string) (string, error) {
-	return p.handler(Request{Prompt: prompt, Command: command})
+// returns the complete response. Cancelling ctx aborts the request.
+func (p *Pipeline) Run(ctx context.Context, req Request) (string, error) {
+	return p.handler(ctx, p.prepare(req))
 }
 
 // RunStream executes the full middleware chain for a streaming request.
 // onChunk is called once per token. Returns the full accumulated response.
-func (p *Pipeline) RunStream(prompt, command string, onChunk func(string)) (string, error) {
-	return p.streamHandler(Request{Prompt: prompt, Command: command}, onChunk)
+// Cancelling ctx aborts the request and returns ctx.Err().
+func (p *Pipeline) RunStream(ctx context.Context, req Request, onChunk func(string)) (string, error) {
+	return p.streamHandler(ctx, p.prepare(req), onChunk)
+}
+
+// prepare stamps the backend identity onto req and ensures Meta is set.
+func (p *Pipeline) prepare(req Request) Request {
+	req.Provider = p.provider.Name()
+	req.Model = p.provider.ModelName()
+	if e, ok := p.provider.(provider.Endpointer); ok {
+		req.Endpoint = e.Endpoint()
+	}
+	if req.Meta == nil {
+		req.Meta = &Meta{}
+	}
+	return req
+}
+
+// chat converts the request into the provider's chat format: the system
+// message, the prior turns, then the current prompt as the final user turn.
+func (r Request) chat() provider.ChatRequest {
+	msgs := make([]provider.Message, 0, len(r.History)+1)
+	msgs = append(msgs, r.History...)
+	msgs = append(msgs, provider.Message{Role: provider.RoleUser, Content: r.Prompt})
+	return provider.ChatRequest{System: r.System, Messages: msgs, Options: r.Options}
 }
@@ -16,6 +16,7 @@
 package provider
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sort"
@@ -24,18 +25,89 @@ import (
 	"github.com/shell-sage/internal/config"
 )
 
+// Role identifies the author of a chat message.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is a single turn in a chat conversation.
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// Options are backend-agnostic generation parameters. Zero values mean "use
+// the backend default". Backends map the fields they support and silently
+// ignore the rest (e.g. NumCtx and KeepAlive are Ollama-specific).
+type Options struct {
+	// Temperature controls sampling randomness; 0 is (near) deterministic.
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// NumCtx is the context window size in tokens.
+	NumCtx int `json:"num_ctx,omitempty"`
+
+	// Seed makes sampling reproducible when combined with a fixed Temperature.
+	Seed *int `json:"seed,omitempty"`
+
+	// Stop lists sequences that end generation when produced.
+	Stop []string `json:"stop,omitempty"`
+
+	// KeepAlive controls how long the model stays loaded after the request
+	// (e.g. "10m", "-1" for forever).
+	KeepAlive string `json:"keep_alive,omitempty"`
+}
+
+// ChatRequest is a backend-agnostic chat-style request: an optional system
+// message followed by the ordered user/assistant turns. The last message is
+// normally the user's current question.
+type ChatRequest struct {
+	System   string
+	Messages []Message
+	Options  Options
+}
+
+// Prompt returns a ChatRequest holding prompt as a single user message.
+// Backends use it to implement Generate/GenerateStream on top of Chat.
+func Prompt(prompt string) ChatRequest {
+	return ChatRequest{Messages: []Message{{Role: RoleUser, Content: prompt}}}
+}
+
 // Provider is the interface all AI backends must implement.
 type Provider interface {
-	// Generate sends a prompt and returns the full response synchronously.
-	Generate(prompt string) (string, error)
+	// Chat sends a chat request and returns the full response synchronously.
+	// Implementations must abort the underlying request when ctx is cancelled.
+	Chat(ctx context.Context, req ChatRequest) (string, error)
 
-	// GenerateStream sends a prompt and calls onChunk for each token received.
-	// Returns the full accumulated response string so callers can use it for
-	// clipboard copy or caching.
-	GenerateStream(prompt string, onChunk func(string)) (string, error)
+	// ChatStream sends a chat request and calls onChunk for each token
+	// received. Returns the full accumulated response string so callers can
+	// use it for clipboard copy or caching. When ctx is cancelled mid-stream
+	// the partial response is returned together with ctx.Err().
+	ChatStream(ctx context.Context, req ChatRequest, onChunk func(string)) (string, error)
+
+	// Generate is a compatibility shim equivalent to Chat(ctx, Prompt(prompt)).
+	Generate(ctx context.Context, prompt string) (string, error)
+
+	// GenerateStream is a compatibility shim equivalent to
+	// ChatStream(ctx, Prompt(prompt), onChunk).
+	GenerateStream(ctx context.Context, prompt string, onChunk func(string)) (string, error)
 
 	// Name returns the unique identifier of this backend (e.g. "ollama").
 	Name() string
+
+	// ModelName returns the model requests are sent to (e.g. "llama3").
+	ModelName() string
+}
+
+// Endpointer is implemented by providers that talk to a configurable
+// server. Two servers may serve different weights under the same model
+// name, so the endpoint is part of a backend's identity.
+type Endpointer interface {
+	// Endpoint returns the base URL requests are sent to.
+	Endpoint() string
 }
 
 // Factory is a constructor function that creates a Provider for a given model.
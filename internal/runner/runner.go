@@ -0,0 +1,225 @@
+// Package runner executes a user command on behalf of `ssage fix -- <cmd>`,
+// streaming its output to the terminal while keeping a bounded tail of it
+// for the fix prompt.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultMaxOutput is the number of trailing output bytes kept when
+	// Options.MaxOutput is zero.
+	DefaultMaxOutput = 8 << 10
+
+	// ExitTimeout is the exit code reported when the command is killed after
+	// exceeding its timeout, matching coreutils timeout(1).
+	ExitTimeout = 124
+
+	// ExitNotFound is the exit code reported when the command cannot be
+	// started, matching POSIX shells.
+	ExitNotFound = 127
+)
+
+// Options controls how a command is run.
+type Options struct {
+	// Timeout kills the command after the given duration. Zero means no limit.
+	Timeout time.Duration
+
+	// MaxOutput bounds the combined stdout/stderr tail kept in
+	// Result.Output. Zero uses DefaultMaxOutput.
+	MaxOutput int
+
+	// Stdin, Stdout and Stderr default to the process's own streams.
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+}
+
+// Result describes a finished command.
+type Result struct {
+	// Command is the command line as displayed to the user.
+	Command string
+
+	ExitCode int
+	Start    time.Time
+	Duration time.Duration
+
+	// Output is the tail of the interleaved stdout and stderr.
+	Output string
+
+	// Truncated is true when earlier output was dropped to respect
+	// Options.MaxOutput.
+	Truncated bool
+
+	// TimedOut is true when the command was killed by Options.Timeout.
+	TimedOut bool
+}
+
+// Failed reports whether the command exited non-zero.
+func (r *Result) Failed() bool {
+	return r.ExitCode != 0
+}
+
+// Run executes argv, passing its output through to opts.Stdout/Stderr while
+// capturing the tail. A single argument containing shell syntax (spaces,
+// pipes, redirections, …) is run through the user's shell so that
+// `ssage fix -- "make && ./run"` behaves as typed.
+//
+// A command that runs and exits non-zero is not an error: the exit status
+// is reported in Result.ExitCode. Run returns an error only when ctx is
+// cancelled; in that case the partial Result is still returned.
+func Run(ctx context.Context, argv []string, opts Options) (*Result, error) {
+	if opts.MaxOutput <= 0 {
+		opts.MaxOutput = DefaultMaxOutput
+	}
+	if opts.Stdin == nil {
+		opts.Stdin = os.Stdin
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+
+	runCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	name, args := resolve(argv)
+	c := exec.CommandContext(runCtx, name, args...)
+	tail := &tailBuffer{max: opts.MaxOutput}
+	c.Stdin = opts.Stdin
+	c.Stdout = io.MultiWriter(opts.Stdout, tail)
+	c.Stderr = io.MultiWriter(opts.Stderr, tail)
+	// Background children that inherit the pipes must not keep Wait blocked.
+	c.WaitDelay = 2 * time.Second
+
+	res := &Result{Command: strings.Join(argv, " "), Start: time.Now()}
+	err := c.Run()
+	res.Duration = time.Since(res.Start)
+	res.Output, res.Truncated = tail.String()
+
+	switch {
+	case ctx.Err() != nil:
+		res.ExitCode = 130
+		return res, ctx.Err()
+	case opts.Timeout > 0 && errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		res.ExitCode = ExitTimeout
+		res.TimedOut = true
+		return res, nil
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		res.ExitCode = 0
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitCode(exitErr)
+	default:
+		// The command could not be started (not found, not executable…).
+		res.ExitCode = ExitNotFound
+		res.Output = err.Error()
+		fmt.Fprintln(opts.Stderr, res.Output)
+	}
+	return res, nil
+}
+
+// resolve returns the program and arguments to execute for argv.
+func resolve(argv []string) (string, []string) {
+	if len(argv) == 1 && strings.ContainsAny(argv[0], " \t\n|&;<>()$`\\\"'*?[]#~=%") {
+		argv = ShellArgs(argv[0])
+	}
+	return argv[0], argv[1:]
+}
+
+// ShellArgs returns the argv that runs line through the user's shell: $SHELL
+// (or /bin/sh) on Unix, cmd.exe on Windows.
+func ShellArgs(line string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", line}
+	}
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return []string{shell, "-c", line}
+}
+
+// ShellArgsFor returns the argv that runs line through shell, a name such
+// as "fish" or "pwsh" or a path to one. An empty shell is the user's shell
+// as in ShellArgs.
+func ShellArgsFor(shell, line string) []string {
+	if shell == "" {
+		return ShellArgs(line)
+	}
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe")
+	switch name {
+	case "cmd":
+		return []string{shell, "/C", line}
+	case "pwsh", "powershell":
+		return []string{shell, "-NoProfile", "-Command", line}
+	}
+	return []string{shell, "-c", line}
+}
+
+// exitCode maps a process exit status to a shell-style exit code, using
+// 128+signal for commands killed by a signal.
+func exitCode(err *exec.ExitError) int {
+	if code := err.ExitCode(); code >= 0 {
+		return code
+	}
+	if ws, ok := err.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return 1
+}
+
+// tailBuffer is an io.Writer that keeps only the last max bytes written.
+// It is safe for concurrent use by the stdout and stderr copiers.
+type tailBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.max; over > 0 {
+		t.buf = t.buf[over:]
+		t.truncated = true
+	}
+	return len(p), nil
+}
+
+// String returns the captured tail and whether output was dropped. When
+// truncated, the partial first line is removed so the tail starts cleanly.
+func (t *tailBuffer) String() (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.buf
+	if t.truncated {
+		if i := bytes.IndexByte(b, '\n'); i >= 0 && i < len(b)-1 {
+			b = b[i+1:]
+		}
+	}
+	return string(b), t.truncated
+}
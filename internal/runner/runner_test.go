@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func skipOnWindows(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+}
+
+func TestRun_ExitCodeAndOutput(t *testing.T) {
+	skipOnWindows(t)
+	var stdout, stderr bytes.Buffer
+	res, err := Run(context.Background(),
+		[]string{"sh", "-c", "echo out; echo err >&2; exit 3"},
+		Options{Stdout: &stdout, Stderr: &stderr, Stdin: strings.NewReader("")})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitCode != 3 || !res.Failed() {
+		t.Errorf("ExitCode = %d, want 3", res.ExitCode)
+	}
+	// Output is passed through and captured.
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Errorf("passthrough stdout=%q stderr=%q", stdout.String(), stderr.String())
+	}
+	if !strings.Contains(res.Output, "out") || !strings.Contains(res.Output, "err") {
+		t.Errorf("Output = %q, want both streams", res.Output)
+	}
+}
+
+func TestRun_ShellString(t *testing.T) {
+	skipOnWindows(t)
+	t.Setenv("SHELL", "/bin/sh")
+	res, err := Run(context.Background(), []string{"echo a | tr a b"},
+		Options{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Stdin: strings.NewReader("")})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitCode != 0 || strings.TrimSpace(res.Output) != "b" {
+		t.Errorf("got exit %d output %q, want 0 and b", res.ExitCode, res.Output)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	skipOnWindows(t)
+	res, err := Run(context.Background(), []string{"sleep", "5"},
+		Options{Timeout: 100 * time.Millisecond, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Stdin: strings.NewReader("")})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.TimedOut || res.ExitCode != ExitTimeout {
+		t.Errorf("TimedOut=%v ExitCode=%d, want true and %d", res.TimedOut, res.ExitCode, ExitTimeout)
+	}
+}
+
+func TestRun_NotFound(t *testing.T) {
+	res, err := Run(context.Background(), []string{"ssage-no-such-command"},
+		Options{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Stdin: strings.NewReader("")})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitCode != ExitNotFound {
+		t.Errorf("ExitCode = %d, want %d", res.ExitCode, ExitNotFound)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	tb := &tailBuffer{max: 10}
+	tb.Write([]byte("line one\n"))
+	tb.Write([]byte("line two\nend\n"))
+	got, truncated := tb.String()
+	if !truncated {
+		t.Error("expected truncation")
+	}
+	// The last 10 bytes are "e two\nend\n"; the partial first line is dropped.
+	if got != "end\n" {
+		t.Errorf("tail = %q, want %q", got, "end\n")
+	}
+}
+
+func TestShellArgsFor(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"fish", []string{"fish", "-c", "ls"}},
+		{"/usr/bin/zsh", []string{"/usr/bin/zsh", "-c", "ls"}},
+		{"pwsh", []string{"pwsh", "-NoProfile", "-Command", "ls"}},
+		{"powershell.exe", []string{"powershell.exe", "-NoProfile", "-Command", "ls"}},
+		{"cmd", []string{"cmd", "/C", "ls"}},
+	}
+	for _, tt := range tests {
+		if got := ShellArgsFor(tt.shell, "ls"); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("ShellArgsFor(%q) = %q, want %q", tt.shell, got, tt.want)
+		}
+	}
+}
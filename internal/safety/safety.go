@@ -0,0 +1,300 @@
+// Package safety flags shell commands that can cause irreversible damage —
+// wiping the filesystem, overwriting block devices, opening up permissions,
+// piping downloads into a shell, rewriting remote history — so that callers
+// can demand an explicit confirmation before running them.
+//
+// The checks are heuristics over the command text. They are tuned to catch
+// the destructive forms a model is likely to suggest, not to be a sandbox:
+// a command with no risks is not guaranteed to be safe.
+package safety
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Risk is one dangerous pattern found in a command.
+type Risk struct {
+	// Rule is a short stable identifier, e.g. "rm-root".
+	Rule string
+
+	// Reason explains the danger to the user.
+	Reason string
+}
+
+// Check returns the risks found in command, in rule order, or nil if none
+// matched.
+func Check(command string) []Risk {
+	var risks []Risk
+	seen := make(map[string]bool)
+	add := func(rule, reason string) {
+		if !seen[rule] {
+			seen[rule] = true
+			risks = append(risks, Risk{Rule: rule, Reason: reason})
+		}
+	}
+
+	for _, args := range segments(command) {
+		if len(args) == 0 {
+			continue
+		}
+		for _, r := range commandRules {
+			if r.match(args) {
+				add(r.rule, r.reason)
+			}
+		}
+	}
+	for _, r := range textRules {
+		if r.re.MatchString(command) {
+			add(r.rule, r.reason)
+		}
+	}
+	return risks
+}
+
+// DownloadExec reports whether command pipes or feeds downloaded content
+// into a shell or interpreter (e.g. `curl … | sh`).
+func DownloadExec(command string) bool {
+	for _, r := range textRules {
+		if r.rule == "download-exec" && r.re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandRule matches the arguments of a single simple command.
+type commandRule struct {
+	rule, reason string
+	match        func(args []string) bool
+}
+
+var commandRules = []commandRule{
+	{"rm-root", "recursively deletes the root, home, current or a system directory", func(args []string) bool {
+		if args[0] != "rm" {
+			return false
+		}
+		recursive, noPreserve := false, false
+		var targets []string
+		for _, a := range args[1:] {
+			switch {
+			case a == "--recursive":
+				recursive = true
+			case a == "--no-preserve-root":
+				noPreserve = true
+			case strings.HasPrefix(a, "--"):
+			case strings.HasPrefix(a, "-") && len(a) > 1:
+				if strings.ContainsAny(a, "rR") {
+					recursive = true
+				}
+			default:
+				targets = append(targets, a)
+			}
+		}
+		if noPreserve {
+			return true
+		}
+		if !recursive {
+			return false
+		}
+		for _, t := range targets {
+			if criticalPath(t) {
+				return true
+			}
+		}
+		return false
+	}},
+	{"dd-device", "dd writes directly to a block device, destroying its data", func(args []string) bool {
+		if args[0] != "dd" {
+			return false
+		}
+		for _, a := range args[1:] {
+			if dev, ok := strings.CutPrefix(a, "of="); ok && blockDevice(dev) {
+				return true
+			}
+		}
+		return false
+	}},
+	{"mkfs", "formats a device, destroying its data", func(args []string) bool {
+		return args[0] == "mkfs" || strings.HasPrefix(args[0], "mkfs.") || args[0] == "wipefs"
+	}},
+	{"chmod-777", "recursively makes files world-writable", func(args []string) bool {
+		if args[0] != "chmod" {
+			return false
+		}
+		recursive, open := false, false
+		for _, a := range args[1:] {
+			switch a {
+			case "-R", "--recursive":
+				recursive = true
+			case "777", "0777", "a+rwx", "ugo+rwx", "o+w", "a+w":
+				open = true
+			}
+			if strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a, "R") {
+				recursive = true
+			}
+		}
+		return recursive && open
+	}},
+	{"chown-root", "recursively changes ownership of the root or a system directory", func(args []string) bool {
+		if args[0] != "chown" && args[0] != "chgrp" {
+			return false
+		}
+		recursive := false
+		for _, a := range args[1:] {
+			if a == "--recursive" || (strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a, "R")) {
+				recursive = true
+			}
+		}
+		if !recursive {
+			return false
+		}
+		for _, a := range args[1:] {
+			if !strings.HasPrefix(a, "-") && criticalPath(a) {
+				return true
+			}
+		}
+		return false
+	}},
+	{"force-push", "force-pushing rewrites remote history and can discard others' commits", func(args []string) bool {
+		if args[0] != "git" {
+			return false
+		}
+		push := false
+		for _, a := range args[1:] {
+			switch {
+			case a == "push":
+				push = true
+			case !push:
+			case a == "-f", a == "--force", strings.HasPrefix(a, "--force-with-lease"), a == "--mirror":
+				return true
+			case strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a, "f"):
+				return true
+			case strings.HasPrefix(a, "+") && len(a) > 1:
+				return true
+			}
+		}
+		return false
+	}},
+}
+
+// textRule matches the raw command text, for constructs that span several
+// simple commands (pipelines, process substitution).
+type textRule struct {
+	rule, reason string
+	re           *regexp.Regexp
+}
+
+var textRules = []textRule{
+	{"download-exec", "runs a script downloaded from the network without reviewing it",
+		regexp.MustCompile(`(?i)\b(curl|wget|fetch|iwr|invoke-webrequest|irm|invoke-restmethod)\b[^|;&]*\|\s*(sudo\s+(-\S+\s+)*)?(env\s+)?(ba|z|k|da|fi|c|tc)?sh\b|` +
+			`(?i)\b(curl|wget|fetch)\b[^|;&]*\|\s*(sudo\s+)?(python[0-9.]*|perl|ruby|node|php)\b|` +
+			`(?i)\b(ba|z|k|da|fi)?sh\s+(-c\s+)?["']?(<\(|\$\()\s*(curl|wget|fetch)\b|` +
+			`(?i)\b(iwr|irm|invoke-webrequest|invoke-restmethod)\b[^|;]*\|\s*(iex|invoke-expression)\b|` +
+			`(?i)\b(iex|invoke-expression)\s*\(?\s*\(?\s*(iwr|irm|invoke-webrequest|invoke-restmethod|new-object\s+net\.webclient)`)},
+	{"device-redirect", "overwrites a block device with redirected output",
+		regexp.MustCompile(`>\s*/dev/(sd[a-z]|hd[a-z]|vd[a-z]|xvd[a-z]|nvme\d|mmcblk\d|disk\d)`)},
+	{"fork-bomb", "spawns processes until the system becomes unresponsive",
+		regexp.MustCompile(`:\s*\(\s*\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`)},
+}
+
+// separators split a command line into simple commands.
+var separators = regexp.MustCompile(`\s*(?:&&|\|\||;|\||&|\n|\$\(|\x60|\(|\))\s*`)
+
+// prefixes are wrappers that run the following command unchanged.
+var prefixes = map[string]bool{
+	"sudo": true, "doas": true, "command": true, "exec": true, "nohup": true,
+	"time": true, "nice": true, "env": true, "xargs": true, "builtin": true,
+}
+
+// segments splits command into simple commands and returns the arguments of
+// each with wrappers (sudo, env, VAR=value …) and surrounding quotes removed.
+func segments(command string) [][]string {
+	var out [][]string
+	for _, seg := range separators.Split(command, -1) {
+		args := strings.Fields(seg)
+		for i := range args {
+			args[i] = strings.Trim(args[i], `"'`)
+		}
+		args = stripWrappers(args)
+		if len(args) > 0 {
+			// Match on the program name, not its path (/bin/rm → rm).
+			if i := strings.LastIndexByte(args[0], '/'); i >= 0 && i < len(args[0])-1 {
+				args[0] = args[0][i+1:]
+			}
+		}
+		out = append(out, args)
+	}
+	return out
+}
+
+// valueOptions lists, per wrapper, the options whose value is the next
+// argument, e.g. the user in "sudo -u root".
+var valueOptions = map[string]map[string]bool{
+	"sudo":  wrapperOptions("-u", "-g", "-C", "-h", "-p", "-D", "-r", "-t", "--user", "--group", "--close-from", "--host", "--prompt", "--chdir", "--role", "--type"),
+	"doas":  wrapperOptions("-u", "-C"),
+	"env":   wrapperOptions("-u", "-C", "-S", "--unset", "--chdir", "--split-string"),
+	"nice":  wrapperOptions("-n", "--adjustment"),
+	"time":  wrapperOptions("-f", "-o", "--format", "--output"),
+	"xargs": wrapperOptions("-a", "-d", "-E", "-I", "-L", "-n", "-P", "-s", "--arg-file", "--delimiter", "--max-args", "--max-procs"),
+}
+
+func wrapperOptions(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// stripWrappers drops leading wrappers, their options (with the values of
+// those that take one) and variable assignments from args.
+func stripWrappers(args []string) []string {
+	wrapper := ""
+	for len(args) > 0 {
+		a := args[0]
+		switch {
+		case prefixes[a]:
+			wrapper = a
+		case strings.Contains(a, "=") && !strings.HasPrefix(a, "-"):
+		case wrapper != "" && strings.HasPrefix(a, "-") && len(a) > 1:
+			// "--user=root" and "-uroot" carry their value; "-u root"
+			// takes the next argument.
+			if valueOptions[wrapper][a] && len(args) > 1 {
+				args = args[1:]
+			}
+		default:
+			return args
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// criticalPath reports whether p is the root, the home directory, a
+// wildcard in them, or a top-level system directory.
+func criticalPath(p string) bool {
+	p = strings.TrimSuffix(strings.TrimSuffix(p, "*"), "/")
+	switch p {
+	case "", "~", "$HOME", "${HOME}", ".", "..":
+		return true
+	}
+	switch strings.TrimPrefix(p, "/") {
+	case "bin", "boot", "dev", "etc", "home", "lib", "lib64", "opt", "proc",
+		"root", "sbin", "srv", "sys", "usr", "var", "Users", "System", "Library":
+		return strings.HasPrefix(p, "/")
+	}
+	return false
+}
+
+// blockDevice reports whether path names a disk or partition device.
+func blockDevice(path string) bool {
+	if !strings.HasPrefix(path, "/dev/") {
+		return false
+	}
+	switch path {
+	case "/dev/null", "/dev/zero", "/dev/stdout", "/dev/stderr", "/dev/tty":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package safety
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string // expected rule, "" for none
+	}{
+		{"rm -rf /", "rm-root"},
+		{"sudo rm -rf /*", "rm-root"},
+		{"rm -fr ~", "rm-root"},
+		{"rm -r -f $HOME/", "rm-root"},
+		{"cd /tmp && /bin/rm --recursive /etc", "rm-root"},
+		{"rm -rf --no-preserve-root /tmp/x", "rm-root"},
+		{"rm -rf ./build", ""},
+		{"rm -rf node_modules", ""},
+		{"rm /", ""},
+
+		{"dd if=ubuntu.iso of=/dev/sdb bs=4M", "dd-device"},
+		{"sudo dd if=/dev/zero of=/dev/nvme0n1", "dd-device"},
+		{"dd if=/dev/zero of=/dev/null count=1", ""},
+		{"dd if=in.img of=out.img", ""},
+
+		{"mkfs.ext4 /dev/sdb1", "mkfs"},
+		{"echo hi > /dev/sda", "device-redirect"},
+		{"echo hi > /dev/null", ""},
+
+		{"chmod -R 777 /var/www", "chmod-777"},
+		{"sudo chmod -R a+rwx .", "chmod-777"},
+		{"chmod 777 script.sh", ""},
+		{"chmod -R 755 public", ""},
+		{"sudo chown -R me /usr", "chown-root"},
+		{"chown -R me ./data", ""},
+
+		{"curl -fsSL https://example.com/install.sh | sh", "download-exec"},
+		{"wget -qO- https://x.io/i | sudo bash", "download-exec"},
+		{`bash -c "$(curl -fsSL https://x.io/i)"`, "download-exec"},
+		{"sh <(curl -s https://x.io/i)", "download-exec"},
+		{"curl https://x.io/get.py | python3", "download-exec"},
+		{"iwr https://x.io/i.ps1 | iex", "download-exec"},
+		{"curl -o install.sh https://x.io/i", ""},
+		{"curl https://api.x.io | jq .", ""},
+
+		{"git push --force origin main", "force-push"},
+		{"git push -f", "force-push"},
+		{"git push origin +main", "force-push"},
+		{"git push --force-with-lease", "force-push"},
+		{"git push -u origin feature", ""},
+		{"git commit -m 'fix' && git push", ""},
+
+		{":(){ :|:& };:", "fork-bomb"},
+
+		{"sudo -u root rm -rf /", "rm-root"},
+		{"sudo -u root dd of=/dev/sda", "dd-device"},
+		{"sudo --user=root rm -rf /", "rm-root"},
+		{"sudo -uroot -g wheel rm -rf /etc", "rm-root"},
+		{"doas -u root mkfs.ext4 /dev/sdb1", "mkfs"},
+		{"env -u HOME -C /tmp rm -rf /", "rm-root"},
+		{"nice -n 10 dd if=/dev/zero of=/dev/sda", "dd-device"},
+		{"sudo -u root ls /root", ""},
+
+		{"ls -la", ""},
+		{"npm install", ""},
+	}
+	for _, tt := range tests {
+		risks := Check(tt.command)
+		if tt.want == "" {
+			if len(risks) != 0 {
+				t.Errorf("Check(%q) = %v, want none", tt.command, risks)
+			}
+			continue
+		}
+		found := false
+		for _, r := range risks {
+			found = found || r.Rule == tt.want
+		}
+		if !found {
+			t.Errorf("Check(%q) = %v, want rule %s", tt.command, risks, tt.want)
+		}
+	}
+}
+
+func TestDownloadExec(t *testing.T) {
+	if !DownloadExec("curl -sL https://get.example.sh | bash -s -- --yes") {
+		t.Error("expected curl | bash to be flagged")
+	}
+	if DownloadExec("rm -rf /") {
+		t.Error("rm -rf / is not a download-exec")
+	}
+}
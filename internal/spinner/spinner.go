@@ -2,14 +2,20 @@ package spinner
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
 // Spinner shows an animated spinner in the terminal while waiting for a result.
 type Spinner struct {
-	frames []string
-	stop   chan struct{}
-	label  string
+	frames  []string
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	started bool
+
+	mu    sync.Mutex // guards label and terminal output
+	label string
 }
 
 // New creates a new Spinner with the given label text.
@@ -17,30 +23,58 @@ func New(label string) *Spinner {
 	return &Spinner{
 		frames: []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
 		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
 		label:  label,
 	}
 }
 
 // Start begins the spinner animation in a background goroutine.
 func (s *Spinner) Start() {
+	s.started = true
 	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(80 * time.Millisecond)
+		defer ticker.Stop()
 		i := 0
 		for {
+			s.mu.Lock()
+			fmt.Printf("\r\033[36m%s\033[0m %s\033[K", s.frames[i%len(s.frames)], s.label)
+			s.mu.Unlock()
+			i++
 			select {
 			case <-s.stop:
 				// Clear the spinner line on exit
 				fmt.Print("\r\033[K")
 				return
-			default:
-				fmt.Printf("\r\033[36m%s\033[0m %s", s.frames[i%len(s.frames)], s.label)
-				i++
-				time.Sleep(80 * time.Millisecond)
+			case <-ticker.C:
 			}
 		}
 	}()
 }
 
-// Stop terminates the spinner and clears its line.
+// SetLabel changes the text shown next to the spinner, e.g. to report
+// progress.
+func (s *Spinner) SetLabel(label string) {
+	s.mu.Lock()
+	s.label = label
+	s.mu.Unlock()
+}
+
+// Println prints a line above the spinner without garbling its animation.
+func (s *Spinner) Println(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Printf("\r\033[K%s\n", line)
+}
+
+// Stop terminates the spinner and clears its line. It blocks until the line
+// has been cleared so callers can print immediately afterwards, and it is
+// safe to call more than once (e.g. from a cancellation path).
 func (s *Spinner) Stop() {
-	s.stop <- struct{}{}
+	s.once.Do(func() {
+		close(s.stop)
+		if s.started {
+			<-s.done
+		}
+	})
 }
@@ -0,0 +1,64 @@
+// Package suggest extracts the runnable command from a model answer written
+// in the structured format ssage asks for: a short explanation followed by
+// the command in a fenced code block.
+package suggest
+
+import (
+	"strings"
+)
+
+// Format is appended to system prompts that expect a command back.
+const Format = "End your answer with the single corrected command in a fenced code block (```sh ... ```). Put nothing after the code block. If no command can help, do not include a code block."
+
+// Command returns the contents of the last fenced code block in response,
+// with shell prompt markers ("$ ") removed, or "" if there is none.
+func Command(response string) string {
+	commands := Commands(response)
+	if len(commands) == 0 {
+		return ""
+	}
+	return commands[len(commands)-1]
+}
+
+// Commands returns the contents of every non-empty fenced code block in
+// response, in order, with shell prompt markers ("$ ") removed.
+func Commands(response string) []string {
+	var (
+		blocks  [][]string
+		block   []string
+		inBlock bool
+	)
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			if inBlock {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			inBlock = !inBlock
+			continue
+		}
+		if inBlock {
+			block = append(block, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	// An unterminated block (e.g. a truncated answer) still counts.
+	if inBlock && len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+
+	var commands []string
+	for _, b := range blocks {
+		lines := make([]string, 0, len(b))
+		for _, l := range b {
+			if rest, ok := strings.CutPrefix(strings.TrimLeft(l, " \t"), "$ "); ok {
+				l = rest
+			}
+			lines = append(lines, l)
+		}
+		if c := strings.TrimSpace(strings.Join(lines, "\n")); c != "" {
+			commands = append(commands, c)
+		}
+	}
+	return commands
+}
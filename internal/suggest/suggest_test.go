@@ -0,0 +1,33 @@
+package suggest
+
+import "testing"
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name, response, want string
+	}{
+		{"none", "- The file does not exist.\n- Check the path.", ""},
+		{"single", "- Missing flag.\n\n```sh\ntar -xzf archive.tgz\n```\n", "tar -xzf archive.tgz"},
+		{"last block wins", "Try:\n```\nls\n```\nor better:\n```bash\nls -la\n```", "ls -la"},
+		{"prompt marker", "```console\n$ make build\n```", "make build"},
+		{"multi-line", "```sh\ncd app \\\n  && make\n```", "cd app \\\n  && make"},
+		{"unterminated", "Fix:\n```sh\ngit pull --rebase", "git pull --rebase"},
+		{"indented", "  ```sh\n  npm ci\n  ```", "npm ci"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Command(tt.response); got != tt.want {
+				t.Errorf("Command() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommands(t *testing.T) {
+	response := "1. By size:\n```sh\nfind . -size +100M\n```\n2. Empty block:\n```\n```\n3. With du:\n```bash\n$ du -ah . | sort -h\n```"
+	got := Commands(response)
+	want := []string{"find . -size +100M", "du -ah . | sort -h"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Commands() = %q, want %q", got, want)
+	}
+}
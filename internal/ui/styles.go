@@ -48,6 +48,14 @@ func BodyStyleDouble(color string) lipgloss.Style {
 		BorderForeground(lipgloss.Color(color))
 }
 
+// BadgeStyle returns a subtle inline label style, e.g. the "cached" marker
+// shown next to a header when an answer was replayed from the cache.
+func BadgeStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#888888")).
+		Italic(true)
+}
+
 // ErrorStyle returns a style for error messages.
 func ErrorStyle() lipgloss.Style {
 	return lipgloss.NewStyle().
@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/shell-sage/cmd"
 	_ "github.com/shell-sage/internal/ollama" // registers the ollama provider via init()
+	_ "github.com/shell-sage/internal/openai" // registers the openai-compatible provider via init()
 )
 
 func main() {